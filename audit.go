@@ -0,0 +1,26 @@
+package llogger
+
+// Audit prints an audit message with log level audit and the actor
+// and action fields set to actor and action. Audit messages are always
+// printed, they are never dropped by sampling or the minimum log level.
+// The log level, actor and action fields can't be overridden by inp or
+// the data of l. If actor or action is empty the message is printed
+// with the critical log level and the auditError field instead, so
+// that it's not lost.
+func (l *Client) Audit(actor string, action string, inp Input) {
+	l = l.orDefault()
+	data := Input{}
+	for k, v := range inp {
+		data[k] = v
+	}
+	data[l.llfn] = "audit"
+	data["actor"] = actor
+	data["action"] = action
+
+	if actor == "" || action == "" {
+		data[l.llfn] = l.cm
+		data["auditError"] = "Audit message is missing actor or action"
+	}
+
+	l.print(call{skip: 2, force: true}, data)
+}
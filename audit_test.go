@@ -0,0 +1,35 @@
+package llogger
+
+import (
+	"testing"
+)
+
+// TestAudit will test that audit messages are printed with their
+// required fields even if sampling and the minimum log level would
+// drop them.
+func TestAudit(t *testing.T) {
+	client := Create(nil, Input{"llogger-minlevel": "error", "llogger-sample": 0, "actor": "data"})
+
+	strs := capture(t, func() {
+		client.Info("Testmessage1", nil)
+		client.Audit("user-1", "delete", Input{"message": "Testmessage2", "actor": "inp", "loglevel": "debug"})
+		client.Audit("", "delete", Input{"message": "Testmessage3"})
+	})
+
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 audit messages but got %q", strs)
+	}
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	switch {
+	case msg1["loglevel"] != "audit" || msg1["message"] != "Testmessage2":
+		t.Fatalf("Expected audit log level in msg1 but got %s", strs[0])
+
+	case msg1["actor"] != "user-1" || msg1["action"] != "delete":
+		t.Fatalf("Expected actor and action to not be overridden in msg1 but got %s", strs[0])
+
+	case msg2["loglevel"] != "error" || msg2["auditError"] == nil || msg2["action"] != "delete":
+		t.Fatalf("Expected critical message with auditError in msg2 but got %s", strs[1])
+	}
+}
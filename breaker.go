@@ -0,0 +1,111 @@
+package llogger
+
+import (
+	"errors"
+	"time"
+)
+
+// defaultCooldown is how long the circuit breaker stays open
+// if llogger-cooldown isn't set.
+const defaultCooldown = 30 * time.Second
+
+// errBreakerOpen is returned for writes dropped by the open
+// circuit breaker.
+var errBreakerOpen = errors.New("llogger: circuit breaker is open")
+
+// breaker is the circuit breaker of a sink. After threshold
+// consecutive write failures it opens and drops all writes until
+// cooldown has passed, then the next write is tried again. It's
+// guarded by the mutex of the sinkOut.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	failures  int       // Consecutive write failures
+	openUntil time.Time // Zero while the breaker is closed
+	dropped   int       // Lines dropped while the breaker was open
+}
+
+// setBreaker will enable the circuit breaker of the sink of l if
+// llogger-breaker is set to the number of consecutive write failures
+// that opens it. The cooldown is set with llogger-cooldown.
+func (l *Client) setBreaker() {
+	threshold, ok := l.configInt("llogger-breaker")
+	cooldown, hasCooldown := l.configDuration("llogger-cooldown")
+	if !ok || threshold <= 0 {
+		return
+	}
+	if !hasCooldown || cooldown <= 0 {
+		cooldown = defaultCooldown
+	}
+
+	l.sink.out.breaker = &breaker{threshold: int(threshold), cooldown: cooldown}
+}
+
+// writeOut will write p to the writer of s through the circuit breaker,
+// if enabled. While the breaker is open p is dropped and counted, and
+// errBreakerOpen is returned. s.mu must be held.
+// Returns error.
+func (s *sink) writeOut(p []byte) error {
+	o := s.out
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	b := o.breaker
+	if b == nil {
+		_, err := o.writer().Write(p)
+		return err
+	}
+
+	if !b.openUntil.IsZero() && time.Now().Before(b.openUntil) {
+		b.dropped += countLines(p)
+		return errBreakerOpen
+	}
+
+	if _, err := o.writer().Write(p); err != nil {
+		b.failures++
+		if b.failures >= b.threshold {
+			b.openUntil = time.Now().Add(b.cooldown)
+		}
+		return err
+	}
+
+	b.failures = 0
+	b.openUntil = time.Time{}
+	return nil
+}
+
+// countLines will return the number of lines in p, at least 1,
+// since a buffered flush writes many lines at once.
+// Returns int.
+func countLines(p []byte) int {
+	n := 0
+	for _, c := range p {
+		if c == '\n' {
+			n++
+		}
+	}
+	if n == 0 {
+		n = 1
+	}
+
+	return n
+}
+
+// BreakerState returns if the circuit breaker of the writer is open
+// and the number of lines it has dropped, see llogger-breaker. The
+// breaker is shared with the clients derived from l. Without a
+// breaker it's never open and nothing is dropped.
+// Returns bool and int.
+func (l *Client) BreakerState() (open bool, dropped int) {
+	l = l.orDefault()
+	o := l.sink.out
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	b := o.breaker
+	if b == nil {
+		return false, 0
+	}
+
+	return !b.openUntil.IsZero() && time.Now().Before(b.openUntil), b.dropped
+}
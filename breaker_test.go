@@ -0,0 +1,65 @@
+package llogger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingWriter is an io.Writer that fails while failing is
+// true and counts the attempted writes.
+type failingWriter struct {
+	syncBuffer
+	failing  bool
+	attempts int
+}
+
+// Write fails if w is failing and otherwise writes p to the buffer.
+// Returns int and error.
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.attempts++
+	if w.failing {
+		return 0, errors.New("network sink is down")
+	}
+	return w.syncBuffer.Write(p)
+}
+
+// TestBreaker will test that the circuit breaker opens after the
+// consecutive write failures, drops lines and then recovers.
+func TestBreaker(t *testing.T) {
+	w := &failingWriter{failing: true}
+	client := Create(nil, Input{"llogger-writer": w, "llogger-breaker": 3, "llogger-cooldown": "50ms"})
+
+	for i := 0; i < 5; i++ {
+		client.Print(Input{"message": "Testmessage"})
+	}
+	open, dropped := client.BreakerState()
+	if !open || dropped != 2 || w.attempts != 3 {
+		t.Fatalf("Expected the breaker to open after 3 failures and drop 2 lines but got %t, %d and %d attempts", open, dropped, w.attempts)
+	}
+
+	// The first write after the cooldown is tried, and reopens
+	// the breaker if it fails.
+	time.Sleep(60 * time.Millisecond)
+	client.Print(Input{"message": "Testmessage"})
+	client.Print(Input{"message": "Testmessage"})
+	if open, dropped = client.BreakerState(); !open || dropped != 3 || w.attempts != 4 {
+		t.Fatalf("Expected the breaker to reopen after a failed retry but got %t, %d and %d attempts", open, dropped, w.attempts)
+	}
+
+	w.failing = false
+	time.Sleep(60 * time.Millisecond)
+	client.WithFields(Input{"service": "llogger-test"}).Print(Input{"message": "Testmessage1"})
+	client.Print(Input{"message": "Testmessage2"})
+	open, dropped = client.BreakerState()
+	strs := strings.Split(strings.TrimSpace(w.String()), "\n")
+
+	switch {
+	case open || dropped != 3:
+		t.Fatalf("Expected the breaker to close after a successful retry but got %t and %d", open, dropped)
+
+	case len(strs) != 2 || decode(t, strs[1])["message"] != "Testmessage2":
+		t.Fatalf("Expected the messages after recovery to be written but got %q", w.String())
+	}
+}
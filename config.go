@@ -0,0 +1,128 @@
+package llogger
+
+import (
+	"strconv"
+	"time"
+)
+
+// configString will return the value of key in l.data if it's a string
+// and delete key from l.data. ok is false if key wasn't set to a string.
+// Returns string and bool.
+func (l *Client) configString(key string) (string, bool) {
+	v, ok := l.data[key]
+	if !ok {
+		return "", false
+	}
+	delete(l.data, key)
+
+	str, ok := v.(string)
+	return str, ok
+}
+
+// configBool will return the value of key in l.data if it's a bool, or
+// a string that can be parsed as a bool, and delete key from l.data.
+// ok is false if key wasn't set to a bool.
+// Returns bool and bool.
+func (l *Client) configBool(key string) (bool, bool) {
+	v, ok := l.data[key]
+	if !ok {
+		return false, false
+	}
+	delete(l.data, key)
+
+	switch b := v.(type) {
+	case bool:
+		return b, true
+
+	case string:
+		parsed, err := strconv.ParseBool(b)
+		return parsed, err == nil
+	}
+
+	return false, false
+}
+
+// configFloat will return the value of key in l.data if it's a number,
+// or a string that can be parsed as a number, and delete key from
+// l.data. ok is false if key wasn't set to a number.
+// Returns float64 and bool.
+func (l *Client) configFloat(key string) (float64, bool) {
+	v, ok := l.data[key]
+	if !ok {
+		return 0, false
+	}
+	delete(l.data, key)
+
+	switch f := v.(type) {
+	case float64:
+		return f, true
+
+	case float32:
+		return float64(f), true
+
+	case int:
+		return float64(f), true
+
+	case int64:
+		return float64(f), true
+
+	case string:
+		parsed, err := strconv.ParseFloat(f, 64)
+		return parsed, err == nil
+	}
+
+	return 0, false
+}
+
+// configInt will return the value of key in l.data if it's an integer,
+// or a string that can be parsed as an integer, and delete key from
+// l.data. ok is false if key wasn't set to an integer.
+// Returns int64 and bool.
+func (l *Client) configInt(key string) (int64, bool) {
+	v, ok := l.data[key]
+	if !ok {
+		return 0, false
+	}
+	delete(l.data, key)
+
+	switch i := v.(type) {
+	case int:
+		return int64(i), true
+
+	case int32:
+		return int64(i), true
+
+	case int64:
+		return i, true
+
+	case string:
+		parsed, err := strconv.ParseInt(i, 10, 64)
+		return parsed, err == nil
+	}
+
+	return 0, false
+}
+
+// configDuration will return the value of key in l.data if it's a
+// time.Duration, or a string that can be parsed as a duration such as
+// "200ms", and delete key from l.data. ok is false if key wasn't set
+// to a duration.
+// Returns time.Duration and bool.
+func (l *Client) configDuration(key string) (time.Duration, bool) {
+	v, ok := l.data[key]
+	if !ok {
+		return 0, false
+	}
+	delete(l.data, key)
+
+	switch d := v.(type) {
+	case time.Duration:
+		return d, true
+
+	case string:
+		parsed, err := time.ParseDuration(d)
+		return parsed, err == nil
+	}
+
+	return 0, false
+}
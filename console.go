@@ -0,0 +1,89 @@
+package llogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// toConsole takes the JSON message raw and returns it as a line
+// that is easy to read in a terminal. The line starts with the time,
+// the upper case log level and the message, followed by all other
+// fields as key=value pairs, in the order set with llogger-keyorder and
+// then sorted, and the resource as "func (file:row)".
+// For example
+// 2006-01-02 15:04:05.999999 INFO Fetched items count=42 main.handler (main.go:12)
+// Returns []byte and error.
+func (l *Client) toConsole(raw []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	out := map[string]interface{}{}
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+
+	parts := []string{}
+	for _, k := range []string{l.tfn, l.llfn, l.mfn} {
+		if v, ok := out[k]; ok {
+			str := consoleValue(v, false)
+			if k == l.llfn {
+				str = strings.ToUpper(str)
+			}
+			parts = append(parts, str)
+		}
+	}
+
+	keys := []string{}
+	for k := range out {
+		if k != l.tfn && k != l.llfn && k != l.mfn && k != l.rfn {
+			keys = append(keys, k)
+		}
+	}
+	orderKeys(keys, l.keyOrder)
+	for _, k := range keys {
+		parts = append(parts, k+"="+consoleValue(out[k], true))
+	}
+
+	switch res := out[l.rfn].(type) {
+	case map[string]interface{}:
+		parts = append(parts, formatResource(res["function"], res["file"], res["row"]))
+
+	case string:
+		parts = append(parts, res)
+	}
+
+	return []byte(strings.Join(parts, " ")), nil
+}
+
+// formatResource will return the resource as "func (file:row)".
+// Returns string.
+func formatResource(function interface{}, file interface{}, row interface{}) string {
+	return fmt.Sprintf("%v (%v:%v)", function, file, row)
+}
+
+// consoleValue will return v as a string. Strings are returned as
+// is unless quote is true and they contain whitespace or quotes.
+// All other values are returned as JSON.
+// Returns string.
+func consoleValue(v interface{}, quote bool) string {
+	if str, ok := v.(string); ok {
+		if quote && (str == "" || strings.ContainsAny(str, " \t\n\"=")) {
+			return fmt.Sprintf("%q", str)
+		}
+		return str
+	}
+
+	raw, _ := json.Marshal(v)
+	return string(raw)
+}
+
+// shortPath will return the last directory and the file
+// name of path, for example "llogger/llogger.go".
+// Returns string.
+func shortPath(path string) string {
+	dir, file := filepath.Split(path)
+	return filepath.Join(filepath.Base(dir), file)
+}
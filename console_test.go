@@ -0,0 +1,29 @@
+package llogger
+
+import (
+	"regexp"
+	"testing"
+)
+
+// TestConsole will test that messages are printed as readable lines
+// with the resource as "func (file:row)" when llogger-format is console.
+func TestConsole(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-format": "console", "llogger-shortpath": true, "service": "llogger-test"})
+	client2 := Create(nil, Input{"llogger-format": "console"})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"loglevel": "info", "message": "Testmessage1", "count": 42, "note": "two words"})
+		client2.Print(Input{"loglevel": "error", "message": "Testmessage2"})
+	})
+
+	expr1 := `^\d{4}-\d\d-\d\d \d\d:\d\d:\d\d(\.\d+)? INFO Testmessage1 count=42 note="two words" service=llogger-test ` +
+		`github\.com/nuttmeister/llogger\.TestConsole\.func1 \([^/ ]+/console_test\.go:\d+\)$`
+	if !regexp.MustCompile(expr1).MatchString(strs[0]) {
+		t.Fatalf("Expected msg1 to match %s but got %s", expr1, strs[0])
+	}
+
+	expr2 := ` ERROR Testmessage2 github\.com/nuttmeister/llogger\.TestConsole\.func1 \(/.+/console_test\.go:\d+\)$`
+	if !regexp.MustCompile(expr2).MatchString(strs[1]) {
+		t.Fatalf("Expected msg2 to match %s but got %s", expr2, strs[1])
+	}
+}
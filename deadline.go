@@ -0,0 +1,92 @@
+package llogger
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// SetOperation sets name as the operation that is currently running.
+// It's added in the operation field of the messages printed when only
+// 25% and 10% of the execution time is left, see llogger-deadlinelog,
+// to pinpoint what ran long. An empty name clears the operation.
+// It's safe to call from several goroutines and it's shared with the
+// clients derived from l, except that WithContext copies the operation
+// into the new client and NewInvocation starts without an operation.
+func (l *Client) SetOperation(name string) {
+	l = l.orDefault()
+	l.monitor.mu.Lock()
+	defer l.monitor.mu.Unlock()
+
+	l.monitor.operation = name
+}
+
+// deadlineLog will print that only percent of the execution time
+// is left with log level level, together with the current operation
+// if one is set.
+func (l *Client) deadlineLog(level string, percent int) {
+	l.monitor.mu.Lock()
+	operation := l.monitor.operation
+	l.monitor.mu.Unlock()
+
+	inp := Input{l.llfn: level, l.mfn: fmt.Sprintf("Only %d%% of execution time left", percent)}
+	if operation != "" {
+		inp["operation"] = operation
+	}
+
+	l.print(call{skip: 2}, inp)
+}
+
+// SubBudget takes fraction and returns a context derived from the
+// context of the client with a deadline at fraction of the execution
+// time left, for operations that should only use part of the time left.
+// If the deadline is reached before cancel is called a message with the
+// warning log level is printed, with the budget in seconds in the budget
+// field and the operation set with SetOperation, if any. The caller of
+// SubBudget is used as resource. fraction must be in (0, 1], otherwise
+// a message with the critical log level is printed and all of the time
+// left is used. Without a context, or with a context without a
+// deadline, the derived context has no deadline. cancel must be called
+// to release the resources of the context.
+// Returns context.Context and context.CancelFunc.
+func (l *Client) SubBudget(fraction float64) (context.Context, context.CancelFunc) {
+	l = l.orDefault()
+	pc, _, _, _ := runtime.Caller(1)
+
+	if !(fraction > 0 && fraction <= 1) {
+		l.print(call{pc: pc}, Input{l.llfn: l.cm, l.mfn: fmt.Sprintf("Sub budget fraction %v isn't in (0, 1]", fraction)})
+		fraction = 1
+	}
+
+	parent := l.context
+	switch {
+	case parent == nil:
+		return context.WithCancel(context.Background())
+
+	case l.deadline.IsZero():
+		return context.WithCancel(parent)
+	}
+
+	budget := time.Duration(float64(time.Until(l.deadline)) * fraction)
+	ctx, cancel := context.WithTimeout(parent, budget)
+
+	go func() {
+		<-ctx.Done()
+		if ctx.Err() != context.DeadlineExceeded || parent.Err() != nil {
+			return
+		}
+
+		l.monitor.mu.Lock()
+		operation := l.monitor.operation
+		l.monitor.mu.Unlock()
+
+		inp := Input{l.llfn: l.wm, l.mfn: "Sub budget exceeded", "budget": budget.Seconds()}
+		if operation != "" {
+			inp["operation"] = operation
+		}
+		l.print(call{pc: pc}, inp)
+	}()
+
+	return ctx, cancel
+}
@@ -0,0 +1,158 @@
+package llogger
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDeadlineLog will test that the deadline messages are printed
+// with the operation set with SetOperation.
+func TestDeadlineLog(t *testing.T) {
+	buf := &syncBuffer{}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	client := Create(ctx, Input{"llogger-writer": buf, "llogger-deadlinelog": true})
+	defer client.Close()
+
+	// Set the operation from several goroutines to check that
+	// SetOperation is safe for concurrent use.
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.SetOperation("other")
+		}()
+	}
+	wg.Wait()
+	client.WithFields(Input{"service": "llogger-test"}).SetOperation("fetch-items")
+
+	// Wait until after the critical message but before the deadline.
+	time.Sleep(190 * time.Millisecond)
+	strs := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 deadline messages but got %q", buf.String())
+	}
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	switch {
+	case msg1["loglevel"] != "warning" || msg1["message"] != "Only 25% of execution time left":
+		t.Fatalf("Expected warning in msg1 but got %s", strs[0])
+
+	case msg2["loglevel"] != "error" || msg2["message"] != "Only 10% of execution time left":
+		t.Fatalf("Expected critical message in msg2 but got %s", strs[1])
+
+	case msg1["operation"] != "fetch-items" || msg2["operation"] != "fetch-items":
+		t.Fatalf("Expected operation fetch-items in both messages but got %s and %s", strs[0], strs[1])
+	}
+}
+
+// TestDeadlineLogDisabled will test that no deadline messages
+// are printed unless enabled.
+func TestDeadlineLogDisabled(t *testing.T) {
+	buf := &syncBuffer{}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := Create(ctx, Input{"llogger-writer": buf})
+	defer client.Close()
+
+	time.Sleep(60 * time.Millisecond)
+	if buf.String() != "" {
+		t.Fatalf("Expected no deadline messages but got %s", buf.String())
+	}
+}
+
+// TestSubBudget will test the deadline of the context returned by
+// SubBudget and that exceeding it is printed.
+func TestSubBudget(t *testing.T) {
+	buf := &syncBuffer{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	client := Create(ctx, Input{"llogger-writer": buf})
+	defer client.Close()
+	client.SetOperation("fetch-items")
+
+	sub1, cancel1 := client.SubBudget(0.05)
+	defer cancel1()
+	sub2, cancel2 := client.SubBudget(0.5)
+	cancel2()
+	sub3, cancel3 := client.SubBudget(2)
+	defer cancel3()
+
+	deadline1, _ := sub1.Deadline()
+	deadline3, _ := sub3.Deadline()
+	left1 := time.Until(deadline1)
+	if left1 <= 0 || left1 > 50*time.Millisecond || !deadline3.Equal(client.deadline) {
+		t.Fatalf("Expected deadlines at 5%% and all of the time left but got %s and %s", left1, time.Until(deadline3))
+	}
+
+	<-sub1.Done()
+	<-sub2.Done()
+	time.Sleep(10 * time.Millisecond)
+
+	strs := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 messages but got %q", buf.String())
+	}
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	budget, _ := msg2["budget"].(float64)
+	res, _ := msg2["resource"].(map[string]interface{})
+
+	switch {
+	case msg1["loglevel"] != "error" || !strings.Contains(msg1["message"].(string), "fraction 2"):
+		t.Fatalf("Expected an error for fraction 2 in msg1 but got %s", strs[0])
+
+	case msg2["loglevel"] != "warning" || msg2["message"] != "Sub budget exceeded" || msg2["operation"] != "fetch-items":
+		t.Fatalf("Expected the exceeded sub budget in msg2 but got %s", strs[1])
+
+	case budget <= 0 || budget > 0.05:
+		t.Fatalf("Expected budget of at most 0.05 seconds in msg2 but got %s", strs[1])
+
+	case !strings.HasSuffix(res["function"].(string), "TestSubBudget"):
+		t.Fatalf("Expected the caller of SubBudget as resource in msg2 but got %s", strs[1])
+	}
+}
+
+// TestWithContextOperation will test that WithContext carries over
+// the operation set with SetOperation and NewInvocation doesn't.
+func TestWithContextOperation(t *testing.T) {
+	buf := &syncBuffer{}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	client := Create(nil, Input{"llogger-writer": buf, "llogger-deadlinelog": true})
+	defer client.Close()
+	client.SetOperation("fetch-items")
+
+	withCtx := client.WithContext(ctx)
+	defer withCtx.Close()
+	invocation := client.NewInvocation(ctx)
+	defer invocation.Close()
+
+	// Wait until after the warnings but before the critical messages.
+	time.Sleep(170 * time.Millisecond)
+	strs := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 deadline messages but got %q", buf.String())
+	}
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	operations := map[interface{}]int{msg1["operation"]: 1}
+	operations[msg2["operation"]]++
+
+	switch {
+	case msg1["loglevel"] != "warning" || msg2["loglevel"] != "warning":
+		t.Fatalf("Expected warnings in msg1 and msg2 but got %s and %s", strs[0], strs[1])
+
+	case operations["fetch-items"] != 1 || operations[nil] != 1:
+		t.Fatalf("Expected operation fetch-items in one message only but got %s and %s", strs[0], strs[1])
+	}
+}
@@ -0,0 +1,46 @@
+package llogger
+
+import (
+	"os"
+	"strings"
+)
+
+// DeploymentProvider returns a deployment identifier, such as a version
+// or a commit, for example read from SSM. It's called once at Create.
+// An empty identifier means that the next source is tried. It can be
+// set with llogger-deployment in Input when creating the client.
+type DeploymentProvider func() string
+
+// resolveDeployment will return the deployment identifier from the
+// first of the sources that has one. The sources can be a comma
+// separated string of environment variable names, a DeploymentProvider
+// or a []interface{} of both in the order to try them.
+// Returns string.
+func resolveDeployment(sources interface{}) string {
+	switch sources := sources.(type) {
+	case string:
+		for _, name := range strings.Split(sources, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if deployment := os.Getenv(name); deployment != "" {
+				return deployment
+			}
+		}
+
+	case DeploymentProvider:
+		return sources()
+
+	case func() string:
+		return sources()
+
+	case []interface{}:
+		for _, source := range sources {
+			if deployment := resolveDeployment(source); deployment != "" {
+				return deployment
+			}
+		}
+	}
+
+	return ""
+}
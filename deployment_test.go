@@ -0,0 +1,55 @@
+package llogger
+
+import (
+	"os"
+	"testing"
+)
+
+// TestDeployment will test that the deployment identifier is
+// resolved from the first source that has one.
+func TestDeployment(t *testing.T) {
+	os.Setenv("DEPLOYMENT_ID", "env-1337")
+	defer os.Unsetenv("DEPLOYMENT_ID")
+
+	calls := 0
+	provider := DeploymentProvider(func() string {
+		calls++
+		return "ssm-1337"
+	})
+	empty := func() string { return "" }
+
+	client1 := Create(nil, Input{"llogger-deployment": provider})
+	client2 := Create(nil, Input{"llogger-deployment": []interface{}{"MISSING_ID", empty, "DEPLOYMENT_ID", provider}})
+	client3 := Create(nil, Input{"llogger-deployment": []interface{}{empty, provider}, "llogger-dpfn": "version"})
+	client4 := Create(nil, Input{"llogger-deployment": "MISSING_ID"})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client1.Print(Input{"message": "Testmessage2"})
+		client2.Print(Input{"message": "Testmessage3"})
+		client3.Print(Input{"message": "Testmessage4"})
+		client4.Print(Input{"message": "Testmessage5"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+	msg4 := decode(t, strs[3])
+	msg5 := decode(t, strs[4])
+
+	switch {
+	case msg1["deployment"] != "ssm-1337" || msg2["deployment"] != "ssm-1337":
+		t.Fatalf("Expected deployment from the provider in msg1 and msg2 but got %s and %s", strs[0], strs[1])
+
+	case calls != 2:
+		t.Fatalf("Expected the provider to be called once per Create but got %d calls", calls)
+
+	case msg3["deployment"] != "env-1337":
+		t.Fatalf("Expected deployment from the first source with one in msg3 but got %s", strs[2])
+
+	case msg4["version"] != "ssm-1337" || msg4["deployment"] != nil:
+		t.Fatalf("Expected the renamed field in msg4 but got %s", strs[3])
+
+	case msg5["deployment"] != nil:
+		t.Fatalf("Expected no deployment without an identifier in msg5 but got %s", strs[4])
+	}
+}
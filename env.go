@@ -0,0 +1,124 @@
+package llogger
+
+import (
+	"os"
+)
+
+// envKeys maps the LLOGGER_* environment variables to the
+// llogger-* keys in Input that they configure.
+var envKeys = map[string]string{
+	"LLOGGER_TIME_FIELD":          "llogger-tfn",
+	"LLOGGER_LOGLEVEL_FIELD":      "llogger-llfn",
+	"LLOGGER_MESSAGE_FIELD":       "llogger-mfn",
+	"LLOGGER_DURATION_FIELD":      "llogger-dfn",
+	"LLOGGER_TIME_LEFT_FIELD":     "llogger-tlfn",
+	"LLOGGER_RESOURCE_FIELD":      "llogger-rfn",
+	"LLOGGER_PREFIX":              "llogger-prefix",
+	"LLOGGER_SUFFIX":              "llogger-suffix",
+	"LLOGGER_MAX_PREFIX":          "llogger-maxprefix",
+	"LLOGGER_PREFIX_SEPARATOR":    "llogger-presep",
+	"LLOGGER_SUFFIX_SEPARATOR":    "llogger-sufsep",
+	"LLOGGER_NEWLINE":             "llogger-newline",
+	"LLOGGER_NO_NEWLINE":          "llogger-nonewline",
+	"LLOGGER_WARNING_MESSAGE":     "llogger-wm",
+	"LLOGGER_CRITICAL_MESSAGE":    "llogger-cm",
+	"LLOGGER_TIME_FORMAT":         "llogger-tf",
+	"LLOGGER_EPOCH_TRUNCATE":      "llogger-epochtrunc",
+	"LLOGGER_DURATIONS":           "llogger-durations",
+	"LLOGGER_FORMAT":              "llogger-format",
+	"LLOGGER_ENVELOPE":            "llogger-envelope",
+	"LLOGGER_ENVELOPE_FIELDS":     "llogger-envfields",
+	"LLOGGER_SHORT_PATH":          "llogger-shortpath",
+	"LLOGGER_SPLIT_FUNC":          "llogger-splitfunc",
+	"LLOGGER_CALLER":              "llogger-caller",
+	"LLOGGER_CALLER_FIELD":        "llogger-cfn",
+	"LLOGGER_EPOCH":               "llogger-epoch",
+	"LLOGGER_EPOCH_FIELD":         "llogger-efn",
+	"LLOGGER_GOROUTINE":           "llogger-goroutine",
+	"LLOGGER_GOROUTINE_FIELD":     "llogger-gfn",
+	"LLOGGER_GOROUTINES":          "llogger-goroutines",
+	"LLOGGER_GOROUTINES_FIELD":    "llogger-ngfn",
+	"LLOGGER_GO_VERSION":          "llogger-goversion",
+	"LLOGGER_GO_VERSION_FIELD":    "llogger-gvfn",
+	"LLOGGER_SCHEMA":              "llogger-schema",
+	"LLOGGER_SCHEMA_FIELD":        "llogger-scfn",
+	"LLOGGER_EVENT_ID":            "llogger-eventid",
+	"LLOGGER_EVENT_ID_FIELD":      "llogger-evfn",
+	"LLOGGER_BUFFERED":            "llogger-buffered",
+	"LLOGGER_ARRAY":               "llogger-array",
+	"LLOGGER_FLUSH_BEFORE":        "llogger-flushbefore",
+	"LLOGGER_BREAKER":             "llogger-breaker",
+	"LLOGGER_BREAKER_COOLDOWN":    "llogger-cooldown",
+	"LLOGGER_DEADLINE_LOG":        "llogger-deadlinelog",
+	"LLOGGER_KEEP_CONTEXT":        "llogger-keepcontext",
+	"LLOGGER_PROCESS":             "llogger-process",
+	"LLOGGER_PROCESS_START_FIELD": "llogger-psfn",
+	"LLOGGER_UPTIME_FIELD":        "llogger-upfn",
+	"LLOGGER_REGION":              "llogger-region",
+	"LLOGGER_REGION_FIELD":        "llogger-rgfn",
+	"LLOGGER_DEPLOYMENT":          "llogger-deployment",
+	"LLOGGER_DEPLOYMENT_FIELD":    "llogger-dpfn",
+	"LLOGGER_LEVEL_TOKEN":         "llogger-leveltoken",
+	"LLOGGER_LEVEL_TOKEN_FIELD":   "llogger-ltfn",
+	"LLOGGER_LEVEL_TOKEN_FORMAT":  "llogger-ltf",
+	"LLOGGER_LEVEL_TOKEN_PREFIX":  "llogger-ltprefix",
+	"LLOGGER_ARN":                 "llogger-arn",
+	"LLOGGER_ARN_FIELD":           "llogger-afn",
+	"LLOGGER_STAGE":               "llogger-stage",
+	"LLOGGER_STAGE_FIELD":         "llogger-stfn",
+	"LLOGGER_STAGE_ENV":           "llogger-stageenv",
+	"LLOGGER_PACKAGE":             "llogger-package",
+	"LLOGGER_PACKAGE_FIELD":       "llogger-pkfn",
+	"LLOGGER_SIZE":                "llogger-size",
+	"LLOGGER_XRAY":                "llogger-xray",
+	"LLOGGER_TRACE_ID_FIELD":      "llogger-xifn",
+	"LLOGGER_TRACE_SAMPLED_FIELD": "llogger-xsfn",
+	"LLOGGER_TIMESTAMPS":          "llogger-timestamps",
+	"LLOGGER_TIMESTAMPS_FIELD":    "llogger-tsfn",
+	"LLOGGER_SIZE_FIELD":          "llogger-szfn",
+	"LLOGGER_SAFE_INTS":           "llogger-safeints",
+	"LLOGGER_COERCE":              "llogger-coerce",
+	"LLOGGER_KEY_ORDER":           "llogger-keyorder",
+	"LLOGGER_EVENT_FIELDS":        "llogger-eventfields",
+	"LLOGGER_EVENT_REDACT":        "llogger-evredact",
+	"LLOGGER_NON_FINITE":          "llogger-nonfinite",
+	"LLOGGER_BUILTINS":            "llogger-builtins",
+	"LLOGGER_SHADOWED":            "llogger-shadowed",
+	"LLOGGER_HTTP_FIELDS":         "llogger-http",
+	"LLOGGER_EMPTY":               "llogger-empty",
+	"LLOGGER_VALIDATION_LEVEL":    "llogger-vlevel",
+	"LLOGGER_MAX_FIELDS":          "llogger-maxfields",
+	"LLOGGER_MAX_SIZE":            "llogger-maxsize",
+	"LLOGGER_MAX_DEPTH":           "llogger-maxdepth",
+	"LLOGGER_SPLIT":               "llogger-split",
+	"LLOGGER_MEMORY_WARNING":      "llogger-memwarn",
+	"LLOGGER_MEMORY_LIMIT":        "llogger-memlimit",
+	"LLOGGER_MEMORY_INTERVAL":     "llogger-meminterval",
+	"LLOGGER_LEVELS":              "llogger-levels",
+	"LLOGGER_MIN_LEVEL":           "llogger-minlevel",
+	"LLOGGER_PRETTY":              "llogger-pretty",
+	"LLOGGER_SAMPLE":              "llogger-sample",
+	"LLOGGER_SUMMARY":             "llogger-summary",
+	"LLOGGER_PERCENTILES":         "llogger-percentiles",
+	"LLOGGER_SEED":                "llogger-seed",
+	"LLOGGER_SAMPLE_KEY":          "llogger-samplekey",
+	"LLOGGER_NO_SAMPLE":           "llogger-nosample",
+	"LLOGGER_SAMPLED":             "llogger-sampled",
+	"LLOGGER_SAMPLED_FIELD":       "llogger-sfn",
+	"LLOGGER_KEEP_CONFIG":         "llogger-keepconfig",
+}
+
+// setEnvConfig will set the llogger-* keys in l.data from their
+// LLOGGER_* environment variables. Keys already present in l.data
+// are left as is, so configuration supplied in Input always takes
+// precedence over the environment.
+func (l *Client) setEnvConfig() {
+	for env, key := range envKeys {
+		if _, ok := l.data[key]; ok {
+			continue
+		}
+		if val, ok := os.LookupEnv(env); ok {
+			l.data[key] = val
+		}
+	}
+}
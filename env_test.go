@@ -0,0 +1,84 @@
+package llogger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestEnv will test that configuration is read from the
+// environment and that Input takes precedence over it.
+func TestEnv(t *testing.T) {
+	os.Setenv("LLOGGER_PREFIX", "env: ")
+	os.Setenv("LLOGGER_TIME_FORMAT", "Unix")
+	os.Setenv("LLOGGER_MESSAGE_FIELD", "env-message")
+	defer os.Unsetenv("LLOGGER_PREFIX")
+	defer os.Unsetenv("LLOGGER_TIME_FORMAT")
+	defer os.Unsetenv("LLOGGER_MESSAGE_FIELD")
+
+	client1 := Create(nil, nil)
+	client2 := Create(nil, Input{"llogger-prefix": "input: ", "llogger-mfn": "input-message"})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"env-message": "Testmessage1"})
+		client2.Print(Input{"input-message": "Testmessage2"})
+	})
+
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 lines from stdout but got %d", len(strs))
+	}
+
+	// Check that the environment is used when Input is not set.
+	if !strings.HasPrefix(strs[0], "env: ") {
+		t.Fatalf("Expected prefix 'env: ' from environment but got %s", strs[0])
+	}
+	msg1 := decode(t, strs[0][5:])
+	switch {
+	case msg1["env-message"] != "Testmessage1":
+		t.Fatalf("Expected env-message from environment to be Testmessage1 but got %v", msg1["env-message"])
+
+	case msg1["time"] == nil:
+		t.Fatalf("Expected time field to be set")
+	}
+	if _, ok := msg1["time"].(float64); !ok {
+		t.Fatalf("Expected time to be Unix timestamp from environment but got %v", msg1["time"])
+	}
+
+	// Check that Input takes precedence over the environment.
+	if !strings.HasPrefix(strs[1], "input: ") {
+		t.Fatalf("Expected prefix 'input: ' from Input but got %s", strs[1])
+	}
+	msg2 := decode(t, strs[1][7:])
+	switch {
+	case msg2["input-message"] != "Testmessage2":
+		t.Fatalf("Expected input-message from Input to be Testmessage2 but got %v", msg2["input-message"])
+
+	case msg2["env-message"] != nil:
+		t.Fatalf("Expected env-message to be overridden by Input but got %v", msg2["env-message"])
+	}
+}
+
+// TestEnvKeepConfig will test that llogger-keepconfig
+// can be set with LLOGGER_KEEP_CONFIG.
+func TestEnvKeepConfig(t *testing.T) {
+	os.Setenv("LLOGGER_KEEP_CONFIG", "true")
+	defer os.Unsetenv("LLOGGER_KEEP_CONFIG")
+
+	client1 := Create(nil, Input{"llogger-tf": "Unix"})
+	client2 := Create(nil, Input{"llogger-tf": "Unix", "llogger-keepconfig": false})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	switch {
+	case msg1["llogger-tf"] != "Unix" || msg1["llogger-keepconfig"] != nil:
+		t.Fatalf("Expected llogger-tf to be kept from environment but got %s", strs[0])
+
+	case msg2["llogger-tf"] != nil:
+		t.Fatalf("Expected Input to take precedence over the environment but got %s", strs[1])
+	}
+}
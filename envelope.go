@@ -0,0 +1,57 @@
+package llogger
+
+import (
+	"encoding/json"
+)
+
+// setEnvelope will set the envelope key from llogger-envelope and the
+// static envelope fields from llogger-envfields in l.data. The fields
+// can be an Input, a map[string]interface{} or a JSON object string, and
+// are marshaled once here. Fields that can't be marshaled are ignored.
+func (l *Client) setEnvelope() {
+	if key, ok := l.configString("llogger-envelope"); ok && key != "" {
+		l.envelope = key
+	}
+
+	fields, ok := l.data["llogger-envfields"]
+	if !ok {
+		return
+	}
+	delete(l.data, "llogger-envfields")
+
+	var inp Input
+	switch fields := fields.(type) {
+	case Input:
+		inp = fields
+
+	case map[string]interface{}:
+		inp = Input(fields)
+
+	case string:
+		json.Unmarshal([]byte(fields), &inp)
+	}
+
+	l.envFields = map[string]json.RawMessage{}
+	for k, v := range inp {
+		if raw, err := json.Marshal(v); err == nil {
+			l.envFields[k] = raw
+		}
+	}
+}
+
+// wrapEnvelope will return the JSON message raw wrapped in an envelope
+// with the static envelope fields and raw under the envelope key, such
+// as {"source":"llogger","payload":{...}}. The envelope key takes
+// precedence over a static field with the same name.
+// Returns []byte.
+func (l *Client) wrapEnvelope(raw []byte) []byte {
+	env := make(map[string]json.RawMessage, len(l.envFields)+1)
+	for k, v := range l.envFields {
+		env[k] = v
+	}
+	env[l.envelope] = raw
+
+	// Marshaling a map of RawMessages of valid JSON can't fail.
+	b, _ := json.Marshal(env)
+	return b
+}
@@ -0,0 +1,46 @@
+package llogger
+
+import (
+	"os"
+	"testing"
+)
+
+// TestEnvelope will test that messages are nested under the envelope
+// key together with the static envelope fields.
+func TestEnvelope(t *testing.T) {
+	os.Setenv("LLOGGER_ENVELOPE_FIELDS", `{"source":"env","version":2}`)
+	defer os.Unsetenv("LLOGGER_ENVELOPE_FIELDS")
+
+	client1 := Create(nil, Input{
+		"llogger-envelope":  "payload",
+		"llogger-envfields": Input{"source": "llogger", "payload": "ignored"},
+		"service":           "llogger-test",
+	})
+	client2 := Create(nil, Input{"llogger-envelope": "record"})
+	client3 := Create(nil, nil)
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+		client3.Print(Input{"message": "Testmessage3"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+	payload1, _ := msg1["payload"].(map[string]interface{})
+	record2, _ := msg2["record"].(map[string]interface{})
+
+	switch {
+	case len(msg1) != 2 || msg1["source"] != "llogger":
+		t.Fatalf("Expected the static envelope fields in msg1 but got %s", strs[0])
+
+	case payload1 == nil || payload1["message"] != "Testmessage1" || payload1["service"] != "llogger-test" || payload1["time"] == nil:
+		t.Fatalf("Expected the message nested under payload in msg1 but got %s", strs[0])
+
+	case msg2["source"] != "env" || msg2["version"] != float64(2) || record2 == nil || record2["message"] != "Testmessage2":
+		t.Fatalf("Expected the envelope fields from the environment in msg2 but got %s", strs[1])
+
+	case msg3["message"] != "Testmessage3":
+		t.Fatalf("Expected no envelope by default in msg3 but got %s", strs[2])
+	}
+}
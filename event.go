@@ -0,0 +1,66 @@
+package llogger
+
+// Event is used to build a message field by field with typed
+// setters instead of an Input map literal. The message is printed
+// when calling Send.
+type Event struct {
+	client *Client
+	data   Input
+}
+
+// NewEvent takes log level level and message msg and returns an
+// *Event that prints to l when calling Send.
+// Returns *Event.
+func (l *Client) NewEvent(level string, msg string) *Event {
+	l = l.orDefault()
+	return &Event{
+		client: l,
+		data:   Input{l.llfn: level, l.mfn: msg},
+	}
+}
+
+// Str sets the field k to the string v.
+// Returns *Event.
+func (e *Event) Str(k string, v string) *Event {
+	e.data[k] = v
+	return e
+}
+
+// Int sets the field k to the int v.
+// Returns *Event.
+func (e *Event) Int(k string, v int) *Event {
+	e.data[k] = v
+	return e
+}
+
+// Float sets the field k to the float64 v.
+// Returns *Event.
+func (e *Event) Float(k string, v float64) *Event {
+	e.data[k] = v
+	return e
+}
+
+// Any sets the field k to v. v must be possible to JSON marshal.
+// Returns *Event.
+func (e *Event) Any(k string, v interface{}) *Event {
+	e.data[k] = v
+	return e
+}
+
+// Events sets the field k to the array events, with each Input as a
+// nested object, and the field k+"Count" to the number of events. This
+// is useful to summarize a batch of sub events in a single message.
+// If the message is larger than llogger-maxsize trailing events are
+// dropped, but the count is still the number of events given.
+// Returns *Event.
+func (e *Event) Events(k string, events []Input) *Event {
+	e.data[k] = events
+	e.data[k+"Count"] = len(events)
+	return e
+}
+
+// Send prints the event. The calling function of Send will be
+// used as resource.
+func (e *Event) Send() {
+	e.client.print(call{skip: 2}, e.data)
+}
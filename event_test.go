@@ -0,0 +1,96 @@
+package llogger
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestEvent will test building and sending an Event.
+func TestEvent(t *testing.T) {
+	client := Create(nil, Input{"service": "llogger-test"})
+
+	strs := capture(t, func() {
+		client.NewEvent("info", "Testmessage1").
+			Str("str", "value").
+			Int("int", 1337).
+			Float("float", 13.37).
+			Any("any", []string{"a", "b"}).
+			Send()
+	})
+
+	if len(strs) != 1 {
+		t.Fatalf("Expected 1 line from stdout but got %d", len(strs))
+	}
+
+	msg := decode(t, strs[0])
+	res, _ := msg["resource"].(map[string]interface{})
+	any, _ := msg["any"].([]interface{})
+
+	switch {
+	case msg["loglevel"] != "info":
+		t.Fatalf("Expected loglevel to be info but got %v", msg["loglevel"])
+
+	case msg["message"] != "Testmessage1":
+		t.Fatalf("Expected message to be Testmessage1 but got %v", msg["message"])
+
+	case msg["service"] != "llogger-test":
+		t.Fatalf("Expected service to be llogger-test but got %v", msg["service"])
+
+	case msg["str"] != "value":
+		t.Fatalf("Expected str to be value but got %v", msg["str"])
+
+	case msg["int"] != float64(1337):
+		t.Fatalf("Expected int to be 1337 but got %v", msg["int"])
+
+	case msg["float"] != 13.37:
+		t.Fatalf("Expected float to be 13.37 but got %v", msg["float"])
+
+	case len(any) != 2 || any[0] != "a" || any[1] != "b":
+		t.Fatalf("Expected any to be [a b] but got %v", msg["any"])
+
+	// Check that resource is the caller of Send.
+	case !strings.HasSuffix(res["file"].(string), "event_test.go"):
+		t.Fatalf("Expected resource file to be event_test.go but got %v", res["file"])
+	}
+}
+
+// TestEventEvents will test adding sub events to an Event
+// and that they respect llogger-maxsize.
+func TestEventEvents(t *testing.T) {
+	client1 := Create(nil, nil)
+	client2 := Create(nil, Input{"llogger-maxsize": 400})
+	events := []Input{
+		{"id": 1, "status": "ok"},
+		{"id": 2, "status": "ok"},
+		{"id": 3, "status": "failed"},
+	}
+	large := []Input{}
+	for i := 0; i < 20; i++ {
+		large = append(large, Input{"id": i, "status": "ok"})
+	}
+
+	strs := capture(t, func() {
+		client1.NewEvent("info", "Testmessage1").Events("events", events).Send()
+		client2.NewEvent("info", "Testmessage2").Events("events", large).Send()
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	arr1, _ := msg1["events"].([]interface{})
+	last1, _ := arr1[len(arr1)-1].(map[string]interface{})
+	arr2, _ := msg2["events"].([]interface{})
+
+	switch {
+	case len(arr1) != 3 || msg1["eventsCount"] != float64(3):
+		t.Fatalf("Expected 3 events in msg1 but got %s", strs[0])
+
+	case last1["id"] != float64(3) || last1["status"] != "failed":
+		t.Fatalf("Expected the last event to be nested object in msg1 but got %s", strs[0])
+
+	case len(strs[1]) > 400:
+		t.Fatalf("Expected msg2 to be at most 400 bytes but got %d", len(strs[1]))
+
+	case len(arr2) == 0 || len(arr2) >= 20 || msg2["eventsCount"] != float64(20) || msg2["truncated"] != true:
+		t.Fatalf("Expected events to be truncated in msg2 but got %s", strs[1])
+	}
+}
@@ -0,0 +1,210 @@
+package llogger
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// hex is used to escape control characters in JSON strings.
+const hex = "0123456789abcdef"
+
+// marshalOutput will JSON marshal out without reflection for the most
+// common value types, string, int, int64, uint64, float64, bool and
+// nil, as well as the resource field. Other values are marshaled with
+// json.Marshal. The keys in first come first, in that order, followed
+// by the rest sorted. With no keys in first the output is identical to
+// json.Marshal(out).
+// Returns []byte and error.
+func marshalOutput(out output, first []string) ([]byte, error) {
+	keys := make([]string, 0, len(out))
+	for k := range out {
+		keys = append(keys, k)
+	}
+	orderKeys(keys, first)
+
+	b := make([]byte, 0, 64*len(out))
+	b = append(b, '{')
+	for i, k := range keys {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendJSONString(b, k)
+		b = append(b, ':')
+
+		var err error
+		if b, err = appendJSONValue(b, out[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	return append(b, '}'), nil
+}
+
+// orderKeys will sort keys with the keys in first at the start, in the
+// order of first, and the rest sorted after them.
+func orderKeys(keys []string, first []string) {
+	if len(first) == 0 {
+		sort.Strings(keys)
+		return
+	}
+
+	rank := make(map[string]int, len(first))
+	for i, k := range first {
+		if _, ok := rank[k]; !ok {
+			rank[k] = i
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		ri, iok := rank[keys[i]]
+		rj, jok := rank[keys[j]]
+		switch {
+		case iok && jok:
+			return ri < rj
+
+		case iok || jok:
+			return iok
+		}
+		return keys[i] < keys[j]
+	})
+}
+
+// appendJSONValue will append the JSON of v to b.
+// Returns []byte and error.
+func appendJSONValue(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, "null"...), nil
+
+	case string:
+		return appendJSONString(b, v), nil
+
+	case bool:
+		return strconv.AppendBool(b, v), nil
+
+	case int:
+		return strconv.AppendInt(b, int64(v), 10), nil
+
+	case int64:
+		return strconv.AppendInt(b, v, 10), nil
+
+	case uint64:
+		return strconv.AppendUint(b, v, 10), nil
+
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			break
+		}
+		return appendJSONFloat(b, v), nil
+
+	case Resource:
+		b = append(b, '{')
+		if v.Package != "" {
+			b = append(b, `"package":`...)
+			b = append(appendJSONString(b, v.Package), ',')
+		}
+		b = append(b, `"function":`...)
+		b = append(appendJSONString(b, v.Function), `,"file":`...)
+		b = append(appendJSONString(b, v.File), `,"row":`...)
+		return append(strconv.AppendInt(b, int64(v.Row), 10), '}'), nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, raw...), nil
+}
+
+// appendJSONFloat will append f to b formatted like encoding/json
+// does, using exponents only for very small and very large values.
+// Returns []byte.
+func appendJSONFloat(b []byte, f float64) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+
+	return b
+}
+
+// appendJSONString will append s to b as a JSON string escaped like
+// encoding/json does, including the HTML characters <, > and &. Strings
+// with other control characters than \n, \r and \t are marshaled with
+// json.Marshal since their escaping differs between Go versions.
+// Returns []byte.
+func appendJSONString(b []byte, s string) []byte {
+	n := len(b)
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+
+			case '\n':
+				b = append(b, '\\', 'n')
+
+			case '\r':
+				b = append(b, '\\', 'r')
+
+			case '\t':
+				b = append(b, '\\', 't')
+
+			case '<', '>', '&':
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+
+			default:
+				raw, _ := json.Marshal(s)
+				return append(b[:n], raw...)
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			// Invalid UTF-8 is replaced with the replacement character.
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+
+		// U+2028 and U+2029 are escaped for JSONP compatibility.
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
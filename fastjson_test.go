@@ -0,0 +1,74 @@
+package llogger
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestMarshalOutput will test that marshalOutput gives the
+// same output as json.Marshal.
+func TestMarshalOutput(t *testing.T) {
+	outs := []output{
+		{},
+		{"string": "value", "int": 1337, "int64": int64(-42), "uint64": uint64(1 << 60), "bool": true, "nil": nil},
+		{"floats": 0.0, "small": 1e-7, "large": 1e21, "negative": -13.37, "max": math.MaxFloat64, "e": 123456789.0},
+		{"escape": "\"quoted\" \\ <html> & \n\r\t", "control": "bell\a", "utf8": "åäö 日本   ", "invalid": "\xff"},
+		{"resource": Resource{Function: "main.main", File: "/main.go", Row: 8}},
+		{"resource": Resource{Package: "main", Function: "(*T).M", File: "<file>", Row: 1}},
+		{"input": Input{"b": 1, "a": []string{"x"}}, "time": time.Unix(0, 0).UTC(), "float32": float32(1.5)},
+		{"key \"quoted\" <": "value"},
+	}
+
+	for _, out := range outs {
+		want, err1 := json.Marshal(out)
+		got, err2 := marshalOutput(out, nil)
+
+		switch {
+		case err1 != nil || err2 != nil:
+			t.Fatalf("Expected no errors but got %v and %v", err1, err2)
+
+		case string(got) != string(want):
+			t.Fatalf("Expected %s but got %s", want, got)
+		}
+	}
+
+	// Check that values json.Marshal can't encode return errors.
+	if _, err := marshalOutput(output{"nan": math.NaN()}, nil); err == nil {
+		t.Fatalf("Expected an error for NaN")
+	}
+}
+
+// benchmarkOutput is a typical all scalar message.
+var benchmarkOutput = output{
+	"time":      "2019-01-01 12:00:00.123456",
+	"loglevel":  "info",
+	"message":   "Fetched items from the database",
+	"service":   "llogger-bench",
+	"requestId": "1337-1234567890",
+	"count":     42,
+	"ratio":     0.75,
+	"cached":    false,
+	"duration":  0.000123,
+	"timeLeft":  2.999877,
+	"resource":  Resource{Function: "main.handler", File: "/go/src/example/main.go", Row: 42},
+}
+
+// BenchmarkMarshalOutput benchmarks marshaling a
+// typical message with marshalOutput.
+func BenchmarkMarshalOutput(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		marshalOutput(benchmarkOutput, nil)
+	}
+}
+
+// BenchmarkJSONMarshal benchmarks marshaling a typical
+// message with json.Marshal for comparison.
+func BenchmarkJSONMarshal(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(benchmarkOutput)
+	}
+}
@@ -0,0 +1,990 @@
+package llogger
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// processStart is the time the process started, or
+// rather when the package was initialized.
+var processStart time.Time
+
+// goVersion is the Go version the binary was built with.
+var goVersion = runtime.Version()
+
+func init() {
+	processStart = time.Now()
+}
+
+// setOptionalFields will enable the optional fields set in l.data.
+// Each optional field is enabled by setting its key to true and the
+// field name can be changed with its field name key.
+func (l *Client) setOptionalFields() {
+	// Epoch timestamp in milliseconds next to the formatted time.
+	if on, _ := l.configBool("llogger-epoch"); on {
+		l.efn = "epoch"
+	}
+	if efn, ok := l.configString("llogger-efn"); ok && l.efn != "" {
+		l.efn = efn
+	}
+
+	// Goroutine ID, only meant for debugging.
+	if on, _ := l.configBool("llogger-goroutine"); on {
+		l.gfn = "goroutine"
+	}
+	if gfn, ok := l.configString("llogger-gfn"); ok && l.gfn != "" {
+		l.gfn = gfn
+	}
+
+	// Number of goroutines, to catch leaks across warm invocations.
+	if on, _ := l.configBool("llogger-goroutines"); on {
+		l.ngfn = "goroutines"
+	}
+	if ngfn, ok := l.configString("llogger-ngfn"); ok && l.ngfn != "" {
+		l.ngfn = ngfn
+	}
+
+	// Go version, to tell runtimes apart when several are in use.
+	if on, _ := l.configBool("llogger-goversion"); on {
+		l.gvfn = "goVersion"
+	}
+	if gvfn, ok := l.configString("llogger-gvfn"); ok && l.gvfn != "" {
+		l.gvfn = gvfn
+	}
+
+	// Process start time and uptime, useful for warm container analysis.
+	if on, _ := l.configBool("llogger-process"); on {
+		l.psfn = "processStart"
+		l.upfn = "uptime"
+	}
+	if psfn, ok := l.configString("llogger-psfn"); ok && l.psfn != "" {
+		l.psfn = psfn
+	}
+	if upfn, ok := l.configString("llogger-upfn"); ok && l.upfn != "" {
+		l.upfn = upfn
+	}
+
+	// AWS region from the environment, skipped if not set.
+	if on, _ := l.configBool("llogger-region"); on {
+		l.rgfn = "region"
+	}
+	if rgfn, ok := l.configString("llogger-rgfn"); ok && l.rgfn != "" {
+		l.rgfn = rgfn
+	}
+	if l.rgfn != "" {
+		l.region = os.Getenv("AWS_REGION")
+		if l.region == "" {
+			l.region = os.Getenv("AWS_DEFAULT_REGION")
+		}
+		if l.region == "" {
+			l.rgfn = ""
+		}
+	}
+
+	// X-Ray trace ID and sampled flag of the invocation.
+	if on, _ := l.configBool("llogger-xray"); on {
+		l.xifn = "traceId"
+		l.xsfn = "traceSampled"
+	}
+	if xifn, ok := l.configString("llogger-xifn"); ok && l.xifn != "" {
+		l.xifn = xifn
+	}
+	if xsfn, ok := l.configString("llogger-xsfn"); ok && l.xsfn != "" {
+		l.xsfn = xsfn
+	}
+
+	// Invoked function ARN from the Lambda context.
+	if on, _ := l.configBool("llogger-arn"); on {
+		l.afn = "functionArn"
+	}
+	if afn, ok := l.configString("llogger-afn"); ok && l.afn != "" {
+		l.afn = afn
+	}
+
+	// Resource as a flat caller string instead of an object.
+	if on, _ := l.configBool("llogger-caller"); on {
+		l.flatCaller = true
+		l.rfn = "caller"
+	}
+	if cfn, ok := l.configString("llogger-cfn"); ok && l.flatCaller {
+		l.rfn = cfn
+	}
+
+	// Deployment identifier, resolved once from the sources.
+	if sources, ok := l.data["llogger-deployment"]; ok {
+		delete(l.data, "llogger-deployment")
+		if l.deployment = resolveDeployment(sources); l.deployment != "" {
+			l.dpfn = "deployment"
+		}
+	}
+	if dpfn, ok := l.configString("llogger-dpfn"); ok && l.dpfn != "" {
+		l.dpfn = dpfn
+	}
+
+	// Schema version of the output, so parsers can branch on it.
+	if schema, ok := l.data["llogger-schema"]; ok {
+		delete(l.data, "llogger-schema")
+		switch schema.(type) {
+		case string, int, int64, float64:
+			if schema != "" {
+				l.schema = schema
+				l.scfn = "schemaVersion"
+			}
+		}
+	}
+	if scfn, ok := l.configString("llogger-scfn"); ok && l.scfn != "" {
+		l.scfn = scfn
+	}
+
+	// Unique ID of each message.
+	if on, _ := l.configBool("llogger-eventid"); on {
+		l.evfn = "eventId"
+	}
+	if evfn, ok := l.configString("llogger-evfn"); ok && l.evfn != "" {
+		l.evfn = evfn
+	}
+
+	// Stage from the alias of the invoked function ARN or
+	// from the environment.
+	if on, _ := l.configBool("llogger-stage"); on {
+		l.stfn = "stage"
+	}
+	if stfn, ok := l.configString("llogger-stfn"); ok && l.stfn != "" {
+		l.stfn = stfn
+	}
+	if l.stfn != "" {
+		env := "STAGE"
+		if name, ok := l.configString("llogger-stageenv"); ok && name != "" {
+			env = name
+		}
+		l.stageEnv = os.Getenv(env)
+	}
+
+	// Package of the caller, cheaper to group by than the function.
+	if on, _ := l.configBool("llogger-package"); on {
+		l.pkfn = "package"
+	}
+	if pkfn, ok := l.configString("llogger-pkfn"); ok && l.pkfn != "" {
+		l.pkfn = pkfn
+	}
+
+	// Several representations of the time in an object.
+	if on, _ := l.configBool("llogger-timestamps"); on {
+		l.tsfn = "timestamps"
+	}
+	if tsfn, ok := l.configString("llogger-tsfn"); ok && l.tsfn != "" {
+		l.tsfn = tsfn
+	}
+
+	// Upper case level token, such as [ERROR], for CloudWatch metric filters.
+	// It's added as a prefix instead of a field if llogger-ltprefix is true.
+	if on, _ := l.configBool("llogger-leveltoken"); on {
+		l.ltfn = "levelToken"
+	}
+	if ltfn, ok := l.configString("llogger-ltfn"); ok && l.ltfn != "" {
+		l.ltfn = ltfn
+	}
+	l.ltf = "[%s]"
+	if ltf, ok := l.configString("llogger-ltf"); ok && strings.Count(ltf, "%s") == 1 {
+		l.ltf = ltf
+	}
+	if prefix, _ := l.configBool("llogger-ltprefix"); prefix && l.ltfn != "" {
+		l.ltfn, l.ltPrefix = "", true
+	}
+
+	// Byte length of the JSON, useful for estimating log costs.
+	if on, _ := l.configBool("llogger-size"); on {
+		l.szfn = "size"
+	}
+	if szfn, ok := l.configString("llogger-szfn"); ok && l.szfn != "" {
+		l.szfn = szfn
+	}
+
+	// Encode integers above 2^53 as strings.
+	if on, ok := l.configBool("llogger-safeints"); ok {
+		l.safeInts = on
+	}
+
+	// Default fields scoped to a log level.
+	if lf, ok := l.data["llogger-levelfields"]; ok {
+		l.levelFields = map[string]Input{}
+		switch lf := lf.(type) {
+		case map[string]Input:
+			for level, inp := range lf {
+				l.levelFields[level] = inp
+			}
+
+		case map[string]map[string]interface{}:
+			for level, inp := range lf {
+				l.levelFields[level] = Input(inp)
+			}
+		}
+		delete(l.data, "llogger-levelfields")
+	}
+
+	// Formatters for values of specific types.
+	if f, ok := l.data["llogger-formatters"].(map[reflect.Type]Formatter); ok {
+		l.formatters = f
+		delete(l.data, "llogger-formatters")
+	}
+
+	// Handling of built in fields set by the user.
+	if builtins, ok := l.configString("llogger-builtins"); ok && (builtins == "user" || builtins == "warn") {
+		l.builtins = builtins
+	}
+
+	// Warn about fields in Input shadowing fields in data.
+	if shadowed, _ := l.configBool("llogger-shadowed"); shadowed {
+		l.shadowed = true
+	}
+
+	// Rendering of the duration and timeLeft fields.
+	if durations, ok := l.configString("llogger-durations"); ok && durations == "string" {
+		l.durations = durations
+	}
+
+	// Handling of NaN and infinite float values.
+	if nonFinite, ok := l.configString("llogger-nonfinite"); ok && (nonFinite == "null" || nonFinite == "error") {
+		l.nonFinite = nonFinite
+	}
+
+	// Handling of Print with an empty Input.
+	if empty, ok := l.configString("llogger-empty"); ok && (empty == "warn" || empty == "minimal") {
+		l.empty = empty
+	}
+
+	// Naming of the fields printed by HTTPRequest.
+	if http, ok := l.configString("llogger-http"); ok && http == "nested" {
+		l.httpNested = true
+	}
+
+	// Log level of ValidationErrors.
+	if level, ok := l.configString("llogger-vlevel"); ok {
+		l.validationLevel = level
+	}
+
+	// Fields whose string values are coerced to numbers and bools.
+	if coerce, ok := l.data["llogger-coerce"]; ok {
+		var keys []string
+		switch coerce := coerce.(type) {
+		case []string:
+			keys = coerce
+
+		case string:
+			keys = strings.Split(coerce, ",")
+		}
+
+		l.coerce = map[string]bool{}
+		for _, k := range keys {
+			if k = strings.TrimSpace(k); k != "" {
+				l.coerce[k] = true
+			}
+		}
+		delete(l.data, "llogger-coerce")
+	}
+
+	// Keys printed first, in order.
+	if order, ok := l.data["llogger-keyorder"]; ok {
+		var keys []string
+		switch order := order.(type) {
+		case []string:
+			keys = order
+
+		case string:
+			keys = strings.Split(order, ",")
+		}
+
+		for _, k := range keys {
+			if k = strings.TrimSpace(k); k != "" {
+				l.keyOrder = append(l.keyOrder, k)
+			}
+		}
+		delete(l.data, "llogger-keyorder")
+	}
+
+	// Fields of the event printed by Start.
+	if fields, ok := l.data["llogger-eventfields"]; ok {
+		var keys []string
+		switch fields := fields.(type) {
+		case []string:
+			keys = fields
+
+		case string:
+			keys = strings.Split(fields, ",")
+		}
+
+		for _, k := range keys {
+			if k = strings.TrimSpace(k); k != "" {
+				l.eventFields = append(l.eventFields, k)
+			}
+		}
+		delete(l.data, "llogger-eventfields")
+	}
+
+	// Keys of the event redacted by Start.
+	if redact, ok := l.data["llogger-evredact"]; ok {
+		var keys []string
+		switch redact := redact.(type) {
+		case []string:
+			keys = redact
+
+		case string:
+			keys = strings.Split(redact, ",")
+		}
+
+		l.eventRedact = map[string]bool{}
+		for _, k := range keys {
+			if k = strings.TrimSpace(k); k != "" {
+				l.eventRedact[k] = true
+			}
+		}
+		delete(l.data, "llogger-evredact")
+	}
+
+	// Max number of fields in a message.
+	if max, ok := l.configInt("llogger-maxfields"); ok && max > 0 {
+		l.maxFields = int(max)
+	}
+
+	// Max size of a message in bytes.
+	if max, ok := l.configInt("llogger-maxsize"); ok && max > 0 {
+		l.maxSize = int(max)
+	}
+
+	// Max depth of nested values.
+	if max, ok := l.configInt("llogger-maxdepth"); ok && max > 0 {
+		l.maxDepth = int(max)
+	}
+}
+
+// addOptionalFields will add all enabled optional
+// fields to out using the per call settings in c.
+func (l *Client) addOptionalFields(c call, out output) {
+	if l.efn != "" {
+		out[l.efn] = l.epoch(c.time) / int64(time.Millisecond)
+	}
+	if l.gfn != "" {
+		out[l.gfn] = goroutineID()
+	}
+	if l.ngfn != "" {
+		out[l.ngfn] = runtime.NumGoroutine()
+	}
+	if l.evfn != "" {
+		out[l.evfn] = newEventID()
+	}
+	if l.gvfn != "" {
+		out[l.gvfn] = goVersion
+	}
+	if l.psfn != "" {
+		out[l.psfn] = l.formatTime(processStart)
+	}
+	if l.upfn != "" {
+		out[l.upfn] = c.time.Sub(processStart).Seconds()
+	}
+	if l.rgfn != "" {
+		out[l.rgfn] = l.region
+	}
+	if l.dpfn != "" {
+		out[l.dpfn] = l.deployment
+	}
+	if l.scfn != "" {
+		out[l.scfn] = l.schema
+	}
+	if l.tsfn != "" {
+		out[l.tsfn] = timestamps(c.time)
+	}
+	l.addTraceFields(c, out)
+	if l.afn != "" && l.arn != "" {
+		out[l.afn] = l.arn
+	}
+	if l.stfn != "" {
+		if stage := l.stage(); stage != "" {
+			out[l.stfn] = stage
+		}
+	}
+	if l.ltfn != "" {
+		if token, ok := l.levelToken(out[l.llfn]); ok {
+			out[l.ltfn] = token
+		}
+	}
+}
+
+// timestamps will return t as epoch seconds, epoch milliseconds
+// and RFC 3339 in UTC with nanoseconds.
+// Returns map[string]interface{}.
+func timestamps(t time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"epochSeconds": t.Unix(),
+		"epochMillis":  t.UnixNano() / 1e6,
+		"rfc3339":      t.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// levelToken will return the log level level formatted with the
+// level token format, for example "[ERROR]". ok is false if level
+// isn't a string.
+// Returns string and bool.
+func (l *Client) levelToken(level interface{}) (string, bool) {
+	str, ok := level.(string)
+	if !ok || str == "" {
+		return "", false
+	}
+
+	return fmt.Sprintf(l.ltf, strings.ToUpper(str)), true
+}
+
+// goroutineID will return the ID of the current goroutine by parsing
+// the first line of its stack trace, since Go has no API for it. This
+// is slow compared to the rest of print and should only be used when
+// debugging. Returns 0 if the ID can't be parsed.
+// Returns uint64.
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+
+	// The stack trace starts with "goroutine 18 [running]:".
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
+
+// newEventID will return a random version 4 UUID. crypto/rand is safe
+// for concurrent use, so IDs are unique across goroutines.
+// Returns string.
+func newEventID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// builtinFields will return the names of all fields set by
+// the client that are enabled.
+// Returns []string.
+func (l *Client) builtinFields() []string {
+	fields := []string{}
+	for _, k := range []string{l.tfn, l.llfn, l.mfn, l.dfn, l.tlfn, l.rfn, l.efn, l.gfn, l.sfn, l.psfn, l.upfn, l.rgfn, l.szfn, l.ltfn, l.tsfn, l.pkfn, l.xifn, l.xsfn, l.afn, l.stfn, l.evfn, l.ngfn, l.dpfn, l.gvfn, l.scfn} {
+		if k != "" {
+			fields = append(fields, k)
+		}
+	}
+
+	return fields
+}
+
+// generatedFields will return the names of all enabled fields
+// whose values are generated by the client.
+// Returns []string.
+func (l *Client) generatedFields() []string {
+	fields := []string{}
+	for _, k := range []string{l.tfn, l.dfn, l.tlfn, l.rfn, l.efn, l.gfn, l.sfn, l.psfn, l.upfn, l.rgfn, l.szfn, l.ltfn, l.tsfn, l.pkfn, l.xifn, l.xsfn, l.afn, l.stfn, l.evfn, l.ngfn, l.dpfn, l.gvfn, l.scfn} {
+		if k != "" {
+			fields = append(fields, k)
+		}
+	}
+
+	return fields
+}
+
+// userFields will return the values from inp, or the data of l, of the
+// generated fields that are set by the user. Returns nil unless
+// llogger-builtins is set, since the values are overwritten anyway.
+// Returns map[string]interface{}.
+func (l *Client) userFields(inp Input) map[string]interface{} {
+	if l.builtins == "" {
+		return nil
+	}
+
+	user := map[string]interface{}{}
+	for _, k := range l.generatedFields() {
+		if v, ok := inp[k]; ok {
+			user[k] = v
+		} else if v, ok := l.data[k]; ok {
+			user[k] = v
+		}
+	}
+
+	return user
+}
+
+// applyUserFields will set the values in user in out if llogger-builtins
+// is user. If it's warn the sorted names of the fields in user are added
+// in the overwrittenFields field instead.
+func (l *Client) applyUserFields(out output, user map[string]interface{}) {
+	if len(user) == 0 {
+		return
+	}
+
+	switch l.builtins {
+	case "user":
+		for k, v := range user {
+			out[k] = v
+		}
+
+	case "warn":
+		keys := []string{}
+		for k := range user {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out["overwrittenFields"] = keys
+	}
+}
+
+// addShadowedFields will add the sorted names of the fields in inp that
+// are also in the data of l in the shadowedFields field of out, if
+// llogger-shadowed is enabled and there are any.
+func (l *Client) addShadowedFields(inp Input, out output) {
+	if !l.shadowed {
+		return
+	}
+
+	keys := []string{}
+	for k := range inp {
+		if _, ok := l.data[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		out["shadowedFields"] = keys
+	}
+}
+
+// limitFields will drop fields from out so that it has at most
+// l.maxFields fields. The built in fields are always kept and the
+// other fields are kept in alphabetical order until the limit is
+// reached. The number of dropped fields is added as fieldsDropped,
+// which doesn't count towards the limit, if any were dropped.
+func (l *Client) limitFields(out output) {
+	if l.maxFields <= 0 || len(out) <= l.maxFields {
+		return
+	}
+
+	builtin := map[string]bool{}
+	for _, k := range l.builtinFields() {
+		builtin[k] = true
+	}
+
+	keys := []string{}
+	kept := 0
+	for k := range out {
+		if builtin[k] {
+			kept++
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	dropped := 0
+	for _, k := range keys {
+		if kept < l.maxFields {
+			kept++
+			continue
+		}
+		delete(out, k)
+		dropped++
+	}
+
+	if dropped > 0 {
+		out["fieldsDropped"] = dropped
+	}
+}
+
+// limitSize will drop fields from out until the JSON raw of out is at
+// most l.maxSize bytes, and return the JSON of what is left. The largest
+// field that isn't a built in field is dropped first. Arrays of Input,
+// such as the ones set by Event.Events, only have their trailing
+// elements dropped when possible. truncated is set to true if
+// anything was dropped. Returns raw as is if nothing can be dropped.
+// Returns []byte and error.
+func (l *Client) limitSize(out output, raw []byte) ([]byte, error) {
+	builtin := map[string]bool{"truncated": true}
+	for _, k := range l.builtinFields() {
+		builtin[k] = true
+	}
+
+	for len(raw) > l.maxSize {
+		// Find the largest field that can be dropped.
+		largest, size := "", 0
+		for k, v := range out {
+			if builtin[k] {
+				continue
+			}
+			if b, _, err := safeMarshal(v, nil); err == nil && len(b) > size {
+				largest, size = k, len(b)
+			}
+		}
+		if largest == "" {
+			return raw, nil
+		}
+
+		excess := len(raw) - l.maxSize
+		if events, ok := out[largest].([]Input); ok && len(events) > 1 {
+			out[largest] = trimEvents(events, excess)
+		} else {
+			delete(out, largest)
+		}
+		out["truncated"] = true
+
+		var err error
+		if raw, err = l.marshal(out); err != nil {
+			return nil, err
+		}
+	}
+
+	return raw, nil
+}
+
+// trimEvents will return events with as many trailing events dropped
+// as needed to make its JSON excess bytes smaller. At least the first
+// event is always kept.
+// Returns []Input.
+func trimEvents(events []Input, excess int) []Input {
+	n := len(events)
+	for n > 1 && excess > 0 {
+		n--
+		b, _, _ := safeMarshal(events[n], nil)
+		excess -= len(b) + 1
+	}
+
+	return events[:n]
+}
+
+// Formatter returns the value to print in place of v. Formatters are
+// registered per type with llogger-formatters in Input, for example to
+// print a time.Duration as "1.5s" instead of as nanoseconds.
+type Formatter func(v interface{}) interface{}
+
+// formatValues will replace all values in out whose type has a
+// formatter in l.formatters with the value returned by it.
+func (l *Client) formatValues(out output) {
+	if len(l.formatters) == 0 {
+		return
+	}
+
+	for k, v := range out {
+		if v == nil {
+			continue
+		}
+		if f, ok := l.formatters[reflect.TypeOf(v)]; ok {
+			out[k] = f(v)
+		}
+	}
+}
+
+// errorValues will replace the error values in out with their
+// messages, and []error values with arrays of their messages, since
+// errors usually marshal as empty objects. Nil errors, including
+// typed nil pointers, are replaced with null.
+func errorValues(out output) {
+	for k, v := range out {
+		switch v := v.(type) {
+		case error:
+			out[k] = errorMessage(v)
+
+		case []error:
+			msgs := make([]interface{}, len(v))
+			for i, err := range v {
+				msgs[i] = errorMessage(err)
+			}
+			out[k] = msgs
+		}
+	}
+}
+
+// errorMessage will return the message of err, or nil if err is nil
+// or a typed nil. If the Error method panics the panic is recovered
+// and returned as the message so that Print never panics.
+// Returns interface{}.
+func errorMessage(err error) (msg interface{}) {
+	if err == nil {
+		return nil
+	}
+	switch rv := reflect.ValueOf(err); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprintf("<error panic: %v>", r)
+		}
+	}()
+
+	return err.Error()
+}
+
+// depthPlaceholder replaces objects and arrays nested deeper
+// than llogger-maxdepth.
+const depthPlaceholder = "<truncated depth>"
+
+// limitDepth will replace objects and arrays in the values of out that
+// are nested deeper than l.maxDepth with depthPlaceholder. An object or
+// array set as a field has depth 1. The values are walked as their JSON
+// so that structs and json.Marshalers are handled like when printed, and
+// only values that were truncated are replaced.
+func (l *Client) limitDepth(out output) {
+	if l.maxDepth == 0 {
+		return
+	}
+
+	for k, v := range out {
+		switch v.(type) {
+		case nil, string, bool, int, int64, uint64, float64, Resource:
+			continue
+		}
+
+		// Values that can't be marshaled, or panic when marshaled,
+		// are left for print to handle.
+		raw, _, err := safeMarshal(v, nil)
+		if err != nil {
+			continue
+		}
+		var val interface{}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&val); err != nil {
+			continue
+		}
+
+		if val, truncated := truncateDepth(val, 1, l.maxDepth); truncated {
+			out[k] = val
+		}
+	}
+}
+
+// truncateDepth will replace the objects and arrays in v that are
+// deeper than max with depthPlaceholder, where v has depth depth.
+// Returns interface{} and bool, true if anything was replaced.
+func truncateDepth(v interface{}, depth int, max int) (interface{}, bool) {
+	truncated := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if depth > max {
+			return depthPlaceholder, true
+		}
+		for k, e := range v {
+			var t bool
+			v[k], t = truncateDepth(e, depth+1, max)
+			truncated = truncated || t
+		}
+
+	case []interface{}:
+		if depth > max {
+			return depthPlaceholder, true
+		}
+		for i, e := range v {
+			var t bool
+			v[i], t = truncateDepth(e, depth+1, max)
+			truncated = truncated || t
+		}
+	}
+
+	return v, truncated
+}
+
+// replaceNonFinite will replace NaN and infinite float values in out,
+// which can't be JSON marshaled, according to l.nonFinite. With string
+// they are replaced with "NaN", "+Inf" or "-Inf" and with null with nil.
+// With error they are left as is so the message fails to marshal.
+// Values nested in maps and slices are replaced too, in copies so the
+// caller's values are not changed, but not values in structs.
+func (l *Client) replaceNonFinite(out output) {
+	if l.nonFinite == "error" {
+		return
+	}
+
+	for k, v := range out {
+		if v, ok := l.nonFiniteValue(v); ok {
+			out[k] = v
+		}
+	}
+}
+
+// nonFiniteValue will return v with its NaN and infinite float values
+// replaced according to l.nonFinite. Maps and slices are copied if any
+// of their values are replaced.
+// Returns interface{} and bool, true if anything was replaced.
+func (l *Client) nonFiniteValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case float64:
+		return l.nonFiniteFloat(v)
+
+	case float32:
+		return l.nonFiniteFloat(float64(v))
+
+	case Input:
+		if m, ok := l.nonFiniteMap(v); ok {
+			return Input(m), true
+		}
+
+	case map[string]interface{}:
+		return l.nonFiniteMap(v)
+
+	case map[string]float64:
+		m := map[string]interface{}{}
+		for k, e := range v {
+			m[k] = e
+		}
+		return l.nonFiniteMap(m)
+
+	case []interface{}:
+		return l.nonFiniteSlice(v)
+
+	case []float64:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = e
+		}
+		return l.nonFiniteSlice(s)
+
+	case []float32:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = e
+		}
+		return l.nonFiniteSlice(s)
+	}
+
+	return v, false
+}
+
+// nonFiniteMap will return a copy of m with its NaN and infinite
+// float values replaced, if it has any.
+// Returns map[string]interface{} and bool, true if anything was replaced.
+func (l *Client) nonFiniteMap(m map[string]interface{}) (map[string]interface{}, bool) {
+	var replaced map[string]interface{}
+	for k, e := range m {
+		e, ok := l.nonFiniteValue(e)
+		if !ok {
+			continue
+		}
+		if replaced == nil {
+			replaced = make(map[string]interface{}, len(m))
+			for mk, me := range m {
+				replaced[mk] = me
+			}
+		}
+		replaced[k] = e
+	}
+
+	return replaced, replaced != nil
+}
+
+// nonFiniteSlice will return a copy of s with its NaN and infinite
+// float values replaced, if it has any.
+// Returns []interface{} and bool, true if anything was replaced.
+func (l *Client) nonFiniteSlice(s []interface{}) ([]interface{}, bool) {
+	var replaced []interface{}
+	for i, e := range s {
+		e, ok := l.nonFiniteValue(e)
+		if !ok {
+			continue
+		}
+		if replaced == nil {
+			replaced = append([]interface{}{}, s...)
+		}
+		replaced[i] = e
+	}
+
+	return replaced, replaced != nil
+}
+
+// nonFiniteFloat will return the replacement for f according to
+// l.nonFinite if f is NaN or infinite.
+// Returns interface{} and bool, true if f was replaced.
+func (l *Client) nonFiniteFloat(f float64) (interface{}, bool) {
+	switch {
+	case !math.IsNaN(f) && !math.IsInf(f, 0):
+		return f, false
+
+	case l.nonFinite == "null":
+		return nil, true
+
+	case math.IsNaN(f):
+		return "NaN", true
+
+	case f > 0:
+		return "+Inf", true
+	}
+
+	return "-Inf", true
+}
+
+// coerceValues will replace the string values of the fields in
+// l.coerce with their value parsed as an integer, float or bool.
+// Values that can't be parsed are left as is.
+func (l *Client) coerceValues(out output) {
+	for k := range l.coerce {
+		if str, ok := out[k].(string); ok {
+			out[k] = coerce(str)
+		}
+	}
+}
+
+// coerce will return str parsed as an int64, float64 or bool, in that
+// order, or str if it can't be parsed as any of them.
+// Returns interface{}.
+func coerce(str string) interface{} {
+	if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(str, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return f
+	}
+	if b, err := strconv.ParseBool(str); err == nil {
+		return b
+	}
+
+	return str
+}
+
+// maxSafeInt is the largest integer that can be represented
+// exactly by a float64, 2^53.
+const maxSafeInt = 1 << 53
+
+// safeInt will return v as a string if it's an int, int64 or
+// uint64 whose absolute value is larger than maxSafeInt, since
+// JSON consumers that parse numbers as float64 would lose its
+// precision. Other values are returned as is.
+// Returns interface{}.
+func safeInt(v interface{}) interface{} {
+	switch i := v.(type) {
+	case int:
+		if i > maxSafeInt || i < -maxSafeInt {
+			return strconv.FormatInt(int64(i), 10)
+		}
+
+	case int64:
+		if i > maxSafeInt || i < -maxSafeInt {
+			return strconv.FormatInt(i, 10)
+		}
+
+	case uint64:
+		if i > maxSafeInt {
+			return strconv.FormatUint(i, 10)
+		}
+	}
+
+	return v
+}
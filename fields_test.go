@@ -0,0 +1,843 @@
+package llogger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestEpoch will test that the epoch field is only set when
+// enabled and that it's consistent with the time field.
+func TestEpoch(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-epoch": true, "llogger-tf": time.RFC3339Nano})
+	client2 := Create(nil, Input{"llogger-epoch": true, "llogger-efn": "custom-epoch", "llogger-tf": "UnixNano"})
+	client3 := Create(nil, nil)
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+		client3.Print(Input{"message": "Testmessage3"})
+	})
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	tm, err := time.Parse(time.RFC3339Nano, msg1["time"].(string))
+	if err != nil {
+		t.Fatalf("Couldn't parse time in msg1. Error %s", err.Error())
+	}
+	epoch1, _ := msg1["epoch"].(float64)
+	epoch2, _ := msg2["custom-epoch"].(float64)
+	nano2, _ := msg2["time"].(float64)
+
+	switch {
+	case int64(epoch1) != tm.UnixNano()/1e6:
+		t.Fatalf("Expected epoch in msg1 to be %d but got %v", tm.UnixNano()/1e6, msg1["epoch"])
+
+	case int64(epoch2)-int64(nano2)/1e6 > 1 || int64(nano2)/1e6-int64(epoch2) > 1:
+		t.Fatalf("Expected custom-epoch in msg2 to match time %v but got %v", msg2["time"], msg2["custom-epoch"])
+
+	case msg2["epoch"] != nil:
+		t.Fatalf("Expected no epoch field in msg2 but got %v", msg2["epoch"])
+
+	case msg3["epoch"] != nil:
+		t.Fatalf("Expected no epoch field when not enabled but got %v", msg3["epoch"])
+	}
+}
+
+// TestGoroutine will test that the goroutine field is set
+// and differs between goroutines.
+func TestGoroutine(t *testing.T) {
+	client := Create(nil, Input{"llogger-goroutine": true})
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1"})
+		done := make(chan struct{})
+		go func() {
+			client.Print(Input{"message": "Testmessage2"})
+			close(done)
+		}()
+		<-done
+	})
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	id1, _ := msg1["goroutine"].(float64)
+	id2, _ := msg2["goroutine"].(float64)
+
+	switch {
+	case id1 <= 0 || id2 <= 0:
+		t.Fatalf("Expected goroutine IDs to be positive but got %v and %v", msg1["goroutine"], msg2["goroutine"])
+
+	case id1 == id2:
+		t.Fatalf("Expected goroutine IDs to differ but both were %v", id1)
+	}
+}
+
+// TestGoroutines will test that the number of goroutines is a
+// positive integer when enabled.
+func TestGoroutines(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-goroutines": true})
+	client2 := Create(nil, Input{"llogger-goroutines": true, "llogger-ngfn": "numGoroutine"})
+	client3 := Create(nil, nil)
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+		client3.Print(Input{"message": "Testmessage3"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+	n, _ := msg1["goroutines"].(float64)
+
+	switch {
+	case n < 1 || n != float64(int(n)):
+		t.Fatalf("Expected goroutines to be a positive integer in msg1 but got %s", strs[0])
+
+	case msg2["numGoroutine"] == nil || msg2["goroutines"] != nil:
+		t.Fatalf("Expected the renamed field in msg2 but got %s", strs[1])
+
+	case msg3["goroutines"] != nil:
+		t.Fatalf("Expected no goroutines when not enabled in msg3 but got %s", strs[2])
+	}
+}
+
+// TestGoVersion will test that the Go version field matches
+// runtime.Version() when enabled.
+func TestGoVersion(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-goversion": true})
+	client2 := Create(nil, Input{"llogger-goversion": true, "llogger-gvfn": "runtime"})
+	client3 := Create(nil, nil)
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+		client3.Print(Input{"message": "Testmessage3"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	switch {
+	case msg1["goVersion"] != runtime.Version():
+		t.Fatalf("Expected goVersion to be %s in msg1 but got %s", runtime.Version(), strs[0])
+
+	case msg2["runtime"] != runtime.Version() || msg2["goVersion"] != nil:
+		t.Fatalf("Expected the renamed field in msg2 but got %s", strs[1])
+
+	case msg3["goVersion"] != nil:
+		t.Fatalf("Expected no goVersion when not enabled in msg3 but got %s", strs[2])
+	}
+}
+
+// TestSchemaVersion will test that the schema version field has
+// the configured value when enabled.
+func TestSchemaVersion(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-schema": "2"})
+	client2 := Create(nil, Input{"llogger-schema": 3, "llogger-scfn": "schema"})
+	client3 := Create(nil, Input{"llogger-schema": ""})
+	client4 := Create(nil, nil)
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+		client3.Print(Input{"message": "Testmessage3"})
+		client4.Print(Input{"message": "Testmessage4"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+	msg4 := decode(t, strs[3])
+
+	switch {
+	case msg1["schemaVersion"] != "2" || msg1["llogger-schema"] != nil:
+		t.Fatalf("Expected schemaVersion to be 2 in msg1 but got %s", strs[0])
+
+	case msg2["schema"] != float64(3) || msg2["schemaVersion"] != nil:
+		t.Fatalf("Expected the renamed field with a number in msg2 but got %s", strs[1])
+
+	case msg3["schemaVersion"] != nil:
+		t.Fatalf("Expected no schemaVersion for an empty value in msg3 but got %s", strs[2])
+
+	case msg4["schemaVersion"] != nil:
+		t.Fatalf("Expected no schemaVersion when not enabled in msg4 but got %s", strs[3])
+	}
+}
+
+// TestMaxFields will test that fields above the max field count
+// are dropped while the built in fields are kept.
+func TestMaxFields(t *testing.T) {
+	client := Create(nil, Input{"llogger-maxfields": 6, "service": "llogger-test"})
+	builtin := Create(nil, Input{"llogger-maxfields": 2})
+
+	fields := Input{"message": "Testmessage1", "loglevel": "info"}
+	for i := 0; i < 100; i++ {
+		fields[fmt.Sprintf("field%03d", i)] = i
+	}
+
+	strs := capture(t, func() {
+		client.WithFields(fields).Print(nil)
+		client.Print(Input{"message": "Testmessage2"})
+		builtin.Print(Input{"message": "Testmessage3"})
+	})
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	// time, loglevel, message and resource are built in so two more fit.
+	switch {
+	case len(msg1) != 7:
+		t.Fatalf("Expected 6 fields and fieldsDropped in msg1 but got %d fields in %s", len(msg1), strs[0])
+
+	// 98 of the fields and service should be dropped.
+	case msg1["fieldsDropped"] != float64(99):
+		t.Fatalf("Expected fieldsDropped in msg1 to be 99 but got %v", msg1["fieldsDropped"])
+
+	case msg1["time"] == nil || msg1["loglevel"] != "info" || msg1["message"] != "Testmessage1" || msg1["resource"] == nil:
+		t.Fatalf("Expected built in fields to be kept in msg1 but got %s", strs[0])
+
+	case msg1["field000"] != float64(0) || msg1["field001"] != float64(1) || msg1["field002"] != nil:
+		t.Fatalf("Expected field000 and field001 to be kept and field002 to be dropped in msg1 but got %s", strs[0])
+
+	case msg2["fieldsDropped"] != nil || msg2["service"] != "llogger-test":
+		t.Fatalf("Expected no fields dropped in msg2 but got %s", strs[1])
+
+	// Only built in fields are over the limit so nothing is dropped.
+	case msg3["fieldsDropped"] != nil || msg3["message"] != "Testmessage3":
+		t.Fatalf("Expected no fieldsDropped with only built in fields in msg3 but got %s", strs[2])
+	}
+}
+
+// TestProcess will test that the process start time is the
+// same and that uptime increases between messages.
+func TestProcess(t *testing.T) {
+	client := Create(nil, Input{"llogger-process": true, "llogger-upfn": "custom-uptime"})
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1"})
+		time.Sleep(10 * time.Millisecond)
+		client.Print(Input{"message": "Testmessage2"})
+	})
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	up1, _ := msg1["custom-uptime"].(float64)
+	up2, _ := msg2["custom-uptime"].(float64)
+
+	switch {
+	case msg1["processStart"] == nil || msg1["processStart"] != msg2["processStart"]:
+		t.Fatalf("Expected processStart to be set and equal but got %v and %v", msg1["processStart"], msg2["processStart"])
+
+	case up1 <= 0:
+		t.Fatalf("Expected custom-uptime to be positive but got %v", msg1["custom-uptime"])
+
+	case up2 < up1+0.01:
+		t.Fatalf("Expected custom-uptime to increase by at least 10ms but got %v and %v", up1, up2)
+
+	case msg1["uptime"] != nil:
+		t.Fatalf("Expected no uptime field when renamed but got %v", msg1["uptime"])
+	}
+}
+
+// TestRegion will test that the region field is set from
+// the environment and skipped when it's not set.
+func TestRegion(t *testing.T) {
+	os.Setenv("AWS_REGION", "eu-north-1")
+	client1 := Create(nil, Input{"llogger-region": true})
+	client2 := Create(nil, Input{"llogger-region": true, "llogger-rgfn": "custom-region"})
+	client3 := Create(nil, nil)
+	os.Unsetenv("AWS_REGION")
+	client4 := Create(nil, Input{"llogger-region": true})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+		client3.Print(Input{"message": "Testmessage3"})
+		client4.Print(Input{"message": "Testmessage4"})
+	})
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+	msg4 := decode(t, strs[3])
+
+	switch {
+	case msg1["region"] != "eu-north-1":
+		t.Fatalf("Expected region in msg1 to be eu-north-1 but got %v", msg1["region"])
+
+	case msg2["custom-region"] != "eu-north-1":
+		t.Fatalf("Expected custom-region in msg2 to be eu-north-1 but got %v", msg2["custom-region"])
+
+	case msg3["region"] != nil:
+		t.Fatalf("Expected no region when not enabled but got %v", msg3["region"])
+
+	case msg4["region"] != nil:
+		t.Fatalf("Expected no region when AWS_REGION is unset but got %v", msg4["region"])
+	}
+}
+
+// TestSafeInts will test that large integers are encoded as
+// strings only when llogger-safeints is enabled.
+func TestSafeInts(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-safeints": true})
+	client2 := Create(nil, nil)
+
+	inp := Input{"big": int64(9007199254740993), "negative": -9007199254740993, "ubig": uint64(1 << 63), "small": int64(1337)}
+	strs := capture(t, func() {
+		client1.Print(inp)
+		client2.Print(inp)
+	})
+
+	msg1 := decode(t, strs[0])
+
+	switch {
+	case msg1["big"] != "9007199254740993":
+		t.Fatalf("Expected big to be a string when enabled but got %v", msg1["big"])
+
+	case msg1["negative"] != "-9007199254740993":
+		t.Fatalf("Expected negative to be a string when enabled but got %v", msg1["negative"])
+
+	case msg1["ubig"] != "9223372036854775808":
+		t.Fatalf("Expected ubig to be a string when enabled but got %v", msg1["ubig"])
+
+	case msg1["small"] != float64(1337):
+		t.Fatalf("Expected small to be a number when enabled but got %v", msg1["small"])
+
+	case !strings.Contains(strs[1], `"big":9007199254740993`):
+		t.Fatalf("Expected big to be a number when not enabled but got %s", strs[1])
+	}
+}
+
+// TestLevelFields will test that default fields for a log level
+// are only added to messages with that log level.
+func TestLevelFields(t *testing.T) {
+	client := Create(nil, Input{
+		"service":             "llogger-test",
+		"llogger-levelfields": map[string]Input{"error": {"alert": "pagerduty", "team": "core"}},
+	})
+
+	strs := capture(t, func() {
+		client.Error("Testmessage1", nil)
+		client.Info("Testmessage2", nil)
+		client.Error("Testmessage3", Input{"team": "other"})
+	})
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	switch {
+	case msg1["alert"] != "pagerduty" || msg1["team"] != "core" || msg1["service"] != "llogger-test":
+		t.Fatalf("Expected error fields in msg1 but got %s", strs[0])
+
+	case msg2["alert"] != nil || msg2["team"] != nil:
+		t.Fatalf("Expected no error fields in info msg2 but got %s", strs[1])
+
+	case msg3["team"] != "other" || msg3["alert"] != "pagerduty":
+		t.Fatalf("Expected team to be overridden in msg3 but got %s", strs[2])
+	}
+}
+
+// TestFormatters will test that values are formatted with the
+// formatter registered for their type.
+func TestFormatters(t *testing.T) {
+	client := Create(nil, Input{
+		"llogger-formatters": map[reflect.Type]Formatter{
+			reflect.TypeOf(time.Duration(0)): func(v interface{}) interface{} {
+				return v.(time.Duration).String()
+			},
+		},
+	})
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1", "elapsed": 1500 * time.Millisecond, "count": 3})
+	})
+	msg := decode(t, strs[0])
+
+	switch {
+	case msg["elapsed"] != "1.5s":
+		t.Fatalf("Expected elapsed to be 1.5s but got %s", strs[0])
+
+	case msg["count"] != float64(3):
+		t.Fatalf("Expected count to be unchanged but got %s", strs[0])
+
+	case msg["llogger-formatters"] != nil:
+		t.Fatalf("Expected llogger-formatters to not be printed but got %s", strs[0])
+	}
+}
+
+// TestErrorValues will test that errors and slices of errors are
+// printed as their messages.
+func TestErrorValues(t *testing.T) {
+	client := Create(nil, nil)
+	errs := []error{errors.New("item 1 failed"), nil, fmt.Errorf("item 3: %v", errors.New("timeout"))}
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1", "errors": errs, "error": errors.New("batch failed")})
+	})
+	msg := decode(t, strs[0])
+
+	switch {
+	case !reflect.DeepEqual(msg["errors"], []interface{}{"item 1 failed", nil, "item 3: timeout"}):
+		t.Fatalf("Expected errors to be an array of the error messages but got %s", strs[0])
+
+	case msg["error"] != "batch failed":
+		t.Fatalf("Expected error to be the error message but got %s", strs[0])
+	}
+}
+
+// nilError is an error whose Error method panics on a nil receiver.
+type nilError struct{ msg string }
+
+func (e *nilError) Error() string {
+	return e.msg
+}
+
+// TestErrorValuesNil will test that typed nil errors, on their own
+// and in a slice of errors, are printed as null instead of panicking.
+func TestErrorValuesNil(t *testing.T) {
+	client := Create(nil, nil)
+	var typed *nilError
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1", "error": error(typed), "errors": []error{typed, &nilError{"failed"}}})
+	})
+	msg := decode(t, strs[0])
+
+	switch {
+	case msg["message"] != "Testmessage1" || !strings.Contains(strs[0], `"error":null`):
+		t.Fatalf("Expected the typed nil error to be null but got %s", strs[0])
+
+	case !reflect.DeepEqual(msg["errors"], []interface{}{nil, "failed"}):
+		t.Fatalf("Expected the typed nil error in the slice to be null but got %s", strs[0])
+	}
+}
+
+// TestSize will test that the size field is only set when enabled
+// and that it's the length of the JSON without the size field.
+func TestSize(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-size": true})
+	client2 := Create(nil, nil)
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	size, _ := msg1["size"].(float64)
+	without := len(strs[0]) - len(fmt.Sprintf(`,"size":%d`, int(size)))
+
+	switch {
+	case size <= 0:
+		t.Fatalf("Expected size to be set in msg1 but got %s", strs[0])
+
+	case int(size) != without:
+		t.Fatalf("Expected size to be %d but got %s", without, strs[0])
+
+	case msg2["size"] != nil:
+		t.Fatalf("Expected no size in msg2 but got %s", strs[1])
+	}
+}
+
+// TestMaxSize will test that the largest fields are dropped
+// until the message fits in llogger-maxsize.
+func TestMaxSize(t *testing.T) {
+	client := Create(nil, Input{"llogger-maxsize": 300})
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1", "small": "value", "large": strings.Repeat("a", 500)})
+		client.Print(Input{"message": "Testmessage2", "small": "value"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	switch {
+	case len(strs[0]) > 300:
+		t.Fatalf("Expected msg1 to be at most 300 bytes but got %d", len(strs[0]))
+
+	case msg1["large"] != nil || msg1["small"] != "value" || msg1["message"] != "Testmessage1" || msg1["truncated"] != true:
+		t.Fatalf("Expected only large to be dropped in msg1 but got %s", strs[0])
+
+	case msg2["truncated"] != nil || msg2["small"] != "value":
+		t.Fatalf("Expected msg2 to be unchanged but got %s", strs[1])
+	}
+}
+
+// TestBuiltins will test the handling of built in fields
+// set by the user with llogger-builtins.
+func TestBuiltins(t *testing.T) {
+	client1 := Create(nil, nil)
+	client2 := Create(nil, Input{"llogger-builtins": "user"})
+	client3 := Create(nil, Input{"llogger-builtins": "warn", "time": "data"})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1", "resource": "user", "time": "user"})
+		client2.Print(Input{"message": "Testmessage2", "resource": "user", "time": "user"})
+		client3.Print(Input{"message": "Testmessage3", "resource": "user"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	overwritten, _ := msg3["overwrittenFields"].([]interface{})
+
+	switch {
+	case msg1["resource"] == "user" || msg1["time"] == "user" || msg1["overwrittenFields"] != nil:
+		t.Fatalf("Expected generated resource and time in msg1 but got %s", strs[0])
+
+	case msg2["resource"] != "user" || msg2["time"] != "user":
+		t.Fatalf("Expected resource and time from the user in msg2 but got %s", strs[1])
+
+	case msg3["resource"] == "user" || msg3["time"] == "data":
+		t.Fatalf("Expected generated resource and time in msg3 but got %s", strs[2])
+
+	case len(overwritten) != 2 || overwritten[0] != "resource" || overwritten[1] != "time":
+		t.Fatalf("Expected overwrittenFields to be [resource time] in msg3 but got %s", strs[2])
+	}
+}
+
+// TestLevelToken will test that the level token matches the
+// log level and the configured format.
+func TestLevelToken(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-leveltoken": true})
+	client2 := Create(nil, Input{"llogger-leveltoken": true, "llogger-ltf": "<%s>", "llogger-ltprefix": true})
+
+	strs := capture(t, func() {
+		client1.Error("Testmessage1", nil)
+		client1.Print(Input{"message": "Testmessage2"})
+		client2.Warn("Testmessage3", nil)
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	switch {
+	case msg1["levelToken"] != "[ERROR]":
+		t.Fatalf("Expected levelToken to be [ERROR] in msg1 but got %s", strs[0])
+
+	case msg2["levelToken"] != nil:
+		t.Fatalf("Expected no levelToken without log level in msg2 but got %s", strs[1])
+
+	case !strings.HasPrefix(strs[2], "<WARNING> {") || strings.Contains(strs[2], "levelToken"):
+		t.Fatalf("Expected <WARNING> as prefix in msg3 but got %s", strs[2])
+	}
+}
+
+// TestKeyOrder will test that the keys in llogger-keyorder are
+// printed first, in order, followed by the rest sorted.
+func TestKeyOrder(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-keyorder": []string{"message", "loglevel", "zeta", "missing"}, "llogger-tf": "Unix"})
+	client2 := Create(nil, Input{"llogger-keyorder": "requestId, message", "llogger-format": "console", "llogger-tf": "Unix"})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"loglevel": "info", "message": "Testmessage1", "alpha": 1, "zeta": 2})
+		client2.Print(Input{"loglevel": "info", "message": "Testmessage2", "alpha": 1, "requestId": "1337"})
+	})
+	decode(t, strs[0])
+
+	switch {
+	case !strings.HasPrefix(strs[0], `{"message":"Testmessage1","loglevel":"info","zeta":2,"alpha":1,"resource":`):
+		t.Fatalf("Expected message, loglevel and zeta first followed by the sorted keys in msg1 but got %s", strs[0])
+
+	case !strings.Contains(strs[1], "Testmessage2 requestId=1337 alpha=1 "):
+		t.Fatalf("Expected requestId before alpha in msg2 but got %s", strs[1])
+	}
+}
+
+// TestCoerce will test that only the string values of the
+// fields in llogger-coerce are parsed.
+func TestCoerce(t *testing.T) {
+	client := Create(nil, Input{"llogger-coerce": "count, ratio, ok, name"})
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1", "count": "42", "ratio": "0.75", "ok": "true", "name": "abc", "id": "1337"})
+	})
+	msg := decode(t, strs[0])
+
+	switch {
+	case msg["count"] != float64(42) || !strings.Contains(strs[0], `"count":42`):
+		t.Fatalf("Expected count to be the number 42 but got %s", strs[0])
+
+	case msg["ratio"] != 0.75 || msg["ok"] != true:
+		t.Fatalf("Expected ratio and ok to be coerced but got %s", strs[0])
+
+	case msg["name"] != "abc" || msg["id"] != "1337":
+		t.Fatalf("Expected name and id to be unchanged but got %s", strs[0])
+	}
+}
+
+// TestTimestamps will test that all representations in the
+// timestamps field are from the same instant as the time field.
+func TestTimestamps(t *testing.T) {
+	client := Create(nil, Input{"llogger-timestamps": true, "llogger-tf": time.RFC3339Nano})
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1"})
+	})
+	msg := decode(t, strs[0])
+
+	ts, _ := msg["timestamps"].(map[string]interface{})
+	tm, err := time.Parse(time.RFC3339Nano, msg["time"].(string))
+	if err != nil || ts == nil {
+		t.Fatalf("Expected time and timestamps to be set but got %s", strs[0])
+	}
+	rfc, err := time.Parse(time.RFC3339Nano, ts["rfc3339"].(string))
+
+	switch {
+	case err != nil || !rfc.Equal(tm):
+		t.Fatalf("Expected rfc3339 to equal time but got %s", strs[0])
+
+	case ts["epochSeconds"] != float64(tm.Unix()):
+		t.Fatalf("Expected epochSeconds to be %d but got %s", tm.Unix(), strs[0])
+
+	case ts["epochMillis"] != float64(tm.UnixNano()/1e6):
+		t.Fatalf("Expected epochMillis to be %d but got %s", tm.UnixNano()/1e6, strs[0])
+	}
+}
+
+// TestPackage will test that the package field is the package
+// of the caller when enabled.
+func TestPackage(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-package": true})
+	client2 := Create(nil, nil)
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	res, _ := msg1["resource"].(map[string]interface{})
+
+	switch {
+	case msg1["package"] != "github.com/nuttmeister/llogger":
+		t.Fatalf("Expected package to be github.com/nuttmeister/llogger but got %s", strs[0])
+
+	case res["function"] != "github.com/nuttmeister/llogger.TestPackage.func1":
+		t.Fatalf("Expected the full function in resource but got %s", strs[0])
+
+	case msg2["package"] != nil:
+		t.Fatalf("Expected no package field when not enabled but got %s", strs[1])
+	}
+}
+
+// TestMaxDepth will test that objects and arrays nested deeper than
+// llogger-maxdepth are truncated.
+func TestMaxDepth(t *testing.T) {
+	type inner struct {
+		Values []int `json:"values"`
+	}
+	client := Create(nil, Input{"llogger-maxdepth": 2})
+	nested := map[string]interface{}{"b": map[string]interface{}{"c": map[string]interface{}{"d": 1}}}
+
+	strs := capture(t, func() {
+		client.Print(Input{
+			"a":       nested,
+			"list":    []interface{}{1, []interface{}{2, []interface{}{3}}},
+			"struct":  map[string]inner{"x": {Values: []int{1}}},
+			"shallow": map[string]int{"id": 9007199254740993},
+		})
+	})
+	msg := decode(t, strs[0])
+	a, _ := msg["a"].(map[string]interface{})
+	b, _ := a["b"].(map[string]interface{})
+	list, _ := msg["list"].([]interface{})
+	list1, _ := list[1].([]interface{})
+	st, _ := msg["struct"].(map[string]interface{})
+	x, _ := st["x"].(map[string]interface{})
+
+	switch {
+	case b == nil || b["c"] != depthPlaceholder:
+		t.Fatalf("Expected a to be truncated below depth 2 but got %s", strs[0])
+
+	case len(list1) != 2 || list1[1] != depthPlaceholder:
+		t.Fatalf("Expected list to be truncated below depth 2 but got %s", strs[0])
+
+	case x == nil || x["values"] != depthPlaceholder:
+		t.Fatalf("Expected struct to be truncated below depth 2 but got %s", strs[0])
+
+	case !strings.Contains(strs[0], `"shallow":{"id":9007199254740993}`):
+		t.Fatalf("Expected shallow to be kept as is but got %s", strs[0])
+
+	case nested["b"].(map[string]interface{})["c"].(map[string]interface{})["d"] != 1:
+		t.Fatalf("Expected the value in Input to not be modified")
+	}
+}
+
+// TestMaxDepthUnmarshalable will test that values that panic when
+// marshaled and nested NaN floats are handled with llogger-maxdepth.
+func TestMaxDepthUnmarshalable(t *testing.T) {
+	client := Create(nil, Input{"llogger-maxdepth": 2})
+	nested := map[string]interface{}{"b": map[string]interface{}{"c": map[string]interface{}{"nan": math.NaN()}}}
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage", "bad": panicMarshaler{}, "a": nested})
+	})
+
+	if len(strs) != 1 {
+		t.Fatalf("Expected 1 message but got %q", strs)
+	}
+	msg := decode(t, strs[0])
+	a, _ := msg["a"].(map[string]interface{})
+	b, _ := a["b"].(map[string]interface{})
+
+	switch {
+	case msg["bad"] != "<marshal panic>":
+		t.Fatalf("Expected bad to be replaced with placeholder but got %s", strs[0])
+
+	case b == nil || b["c"] != depthPlaceholder:
+		t.Fatalf("Expected a with a nested NaN to be truncated below depth 2 but got %s", strs[0])
+	}
+}
+
+// TestShadowed will test that the names of fields in Input shadowing
+// fields of the client are added when llogger-shadowed is enabled.
+func TestShadowed(t *testing.T) {
+	client1 := Create(nil, Input{"service": "llogger-test", "version": "1"})
+	client2 := Create(nil, Input{"service": "llogger-test", "version": "1", "llogger-shadowed": true})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"service": "Testservice1"})
+		client2.Print(Input{"service": "Testservice2", "version": "2", "message": "Testmessage2"})
+		client2.Print(Input{"message": "Testmessage3"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+	shadowed, _ := msg2["shadowedFields"].([]interface{})
+
+	switch {
+	case msg1["service"] != "Testservice1" || msg1["shadowedFields"] != nil:
+		t.Fatalf("Expected a silent override by default in msg1 but got %s", strs[0])
+
+	case msg2["service"] != "Testservice2" || len(shadowed) != 2 || shadowed[0] != "service" || shadowed[1] != "version":
+		t.Fatalf("Expected the override and the shadowed fields in msg2 but got %s", strs[1])
+
+	case msg3["shadowedFields"] != nil:
+		t.Fatalf("Expected no shadowedFields without shadowed fields in msg3 but got %s", strs[2])
+	}
+}
+
+// TestNonFinite will test that NaN and infinite floats are replaced
+// so that the message is still printed.
+func TestNonFinite(t *testing.T) {
+	client1 := Create(nil, nil)
+	client2 := Create(nil, Input{"llogger-nonfinite": "null"})
+	client3 := Create(nil, Input{"llogger-nonfinite": "error", "llogger-onerror": ErrorHandler(func(err error) {})})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1", "nan": math.NaN(), "inf": math.Inf(1), "ninf": float32(math.Inf(-1)), "ratio": 0.5})
+		client2.Print(Input{"message": "Testmessage2", "nan": math.NaN()})
+		client3.Print(Input{"message": "Testmessage3", "nan": math.NaN()})
+	})
+
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 messages but got %q", strs)
+	}
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	nan2, has2 := msg2["nan"]
+
+	switch {
+	case msg1["nan"] != "NaN" || msg1["inf"] != "+Inf" || msg1["ninf"] != "-Inf" || msg1["ratio"] != 0.5:
+		t.Fatalf("Expected NaN and infinite floats replaced with strings in msg1 but got %s", strs[0])
+
+	case !has2 || nan2 != nil:
+		t.Fatalf("Expected NaN replaced with null in msg2 but got %s", strs[1])
+
+	case client3.LastError() == nil:
+		t.Fatalf("Expected a marshal error with error")
+	}
+}
+
+// TestNonFiniteNested will test that NaN and infinite floats nested
+// in maps and slices are replaced without changing the caller's values.
+func TestNonFiniteNested(t *testing.T) {
+	client := Create(nil, nil)
+	nested := map[string]interface{}{"nan": math.NaN(), "ratio": 0.5}
+	list := []interface{}{1, math.Inf(1)}
+
+	strs := capture(t, func() {
+		client.Print(Input{
+			"message": "Testmessage",
+			"nested":  nested,
+			"list":    list,
+			"floats":  []float64{math.Inf(-1), 2},
+			"input":   Input{"deep": []interface{}{Input{"nan": float32(math.NaN())}}},
+		})
+	})
+
+	if len(strs) != 1 {
+		t.Fatalf("Expected 1 message but got %q", strs)
+	}
+	msg := decode(t, strs[0])
+	m, _ := msg["nested"].(map[string]interface{})
+	l, _ := msg["list"].([]interface{})
+	f, _ := msg["floats"].([]interface{})
+	in, _ := msg["input"].(map[string]interface{})
+	deep, _ := in["deep"].([]interface{})
+
+	switch {
+	case m["nan"] != "NaN" || m["ratio"] != 0.5:
+		t.Fatalf("Expected NaN replaced in nested map but got %s", strs[0])
+
+	case len(l) != 2 || l[1] != "+Inf" || len(f) != 2 || f[0] != "-Inf" || f[1] != float64(2):
+		t.Fatalf("Expected infinite floats replaced in slices but got %s", strs[0])
+
+	case len(deep) != 1 || deep[0].(map[string]interface{})["nan"] != "NaN":
+		t.Fatalf("Expected NaN replaced in deeply nested Input but got %s", strs[0])
+
+	case !math.IsNaN(nested["nan"].(float64)) || !math.IsInf(list[1].(float64), 1):
+		t.Fatalf("Expected the caller's values to not be changed but got %v and %v", nested, list)
+	}
+}
+
+// TestEventID will test that the event IDs of messages printed
+// from many goroutines are distinct.
+func TestEventID(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-eventid": true})
+	client2 := Create(nil, nil)
+
+	var wg sync.WaitGroup
+	strs := capture(t, func() {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					client1.Print(Input{"message": "Testmessage"})
+				}
+			}()
+		}
+		wg.Wait()
+		client2.Print(Input{"message": "Testmessage"})
+	})
+
+	if len(strs) != 501 {
+		t.Fatalf("Expected 501 messages but got %d", len(strs))
+	}
+
+	ids := map[string]bool{}
+	for _, str := range strs[:500] {
+		id, _ := decode(t, str)["eventId"].(string)
+		if len(id) != 36 || id[14] != '4' || ids[id] {
+			t.Fatalf("Expected a distinct UUID as eventId but got %s", str)
+		}
+		ids[id] = true
+	}
+
+	if msg := decode(t, strs[500]); msg["eventId"] != nil {
+		t.Fatalf("Expected no eventId when not enabled but got %s", strs[500])
+	}
+}
@@ -0,0 +1,47 @@
+package llogger
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// toFlat takes the JSON message raw and returns it with all nested
+// objects flattened to one level with . between the keys, so that
+// resource becomes resource.function, resource.file and resource.row.
+// Arrays are added as JSON strings so every field has a scalar type
+// that CloudWatch Logs Insights can query.
+// Returns []byte and error.
+func toFlat(raw []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	out := map[string]interface{}{}
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+
+	flat := map[string]interface{}{}
+	for k, v := range out {
+		addFlatField(flat, k, v)
+	}
+
+	return json.Marshal(flat)
+}
+
+// addFlatField will add v as key to flat. Maps are flattened
+// and arrays are added as JSON strings.
+func addFlatField(flat map[string]interface{}, key string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, nv := range v {
+			addFlatField(flat, key+"."+k, nv)
+		}
+
+	case []interface{}:
+		raw, _ := json.Marshal(v)
+		flat[key] = string(raw)
+
+	default:
+		flat[key] = v
+	}
+}
@@ -0,0 +1,54 @@
+package llogger
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestFlat will test that nested objects are flattened
+// when llogger-format is flat.
+func TestFlat(t *testing.T) {
+	client := Create(nil, Input{"llogger-format": "flat", "service": "llogger-test"})
+
+	strs := capture(t, func() {
+		client.Print(Input{
+			"message": "Testmessage1",
+			"user":    map[string]interface{}{"id": 1337, "address": map[string]string{"city": "Hill Valley"}},
+			"tags":    []string{"a", "b"},
+		})
+	})
+
+	msg := decode(t, strs[0])
+
+	for k, v := range msg {
+		if _, ok := v.(map[string]interface{}); ok {
+			t.Fatalf("Expected no nested objects but got %s for %s", v, k)
+		}
+	}
+
+	switch {
+	case msg["message"] != "Testmessage1":
+		t.Fatalf("Expected message to be Testmessage1 but got %v", msg["message"])
+
+	case msg["service"] != "llogger-test":
+		t.Fatalf("Expected service to be llogger-test but got %v", msg["service"])
+
+	case msg["resource"] != nil:
+		t.Fatalf("Expected no nested resource but got %v", msg["resource"])
+
+	case !strings.HasSuffix(msg["resource.file"].(string), "flat_test.go"):
+		t.Fatalf("Expected resource.file to be flat_test.go but got %v", msg["resource.file"])
+
+	case msg["resource.function"] == nil || msg["resource.row"] == nil:
+		t.Fatalf("Expected resource.function and resource.row to be set but got %s", strs[0])
+
+	case msg["user.id"] != float64(1337):
+		t.Fatalf("Expected user.id to be 1337 but got %v", msg["user.id"])
+
+	case msg["user.address.city"] != "Hill Valley":
+		t.Fatalf("Expected user.address.city to be Hill Valley but got %v", msg["user.address.city"])
+
+	case msg["tags"] != `["a","b"]`:
+		t.Fatalf("Expected tags to be a JSON string but got %v", msg["tags"])
+	}
+}
@@ -0,0 +1,124 @@
+package llogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// gelfLevels maps log levels to GELF (syslog) severity levels.
+var gelfLevels = map[string]int{
+	"emergency": 0,
+	"alert":     1,
+	"critical":  2,
+	"error":     3,
+	"warning":   4,
+	"notice":    5,
+	"info":      6,
+	"debug":     7,
+}
+
+// toGELF takes the JSON message raw printed at t and returns it as a
+// GELF 1.1 message. The message field is used as short_message and the
+// log level field as level. All other fields are added as additional
+// fields prefixed with _, nested objects are flattened with _ between
+// the keys and arrays are added as JSON strings.
+// Returns []byte and error.
+func (l *Client) toGELF(raw []byte, t time.Time) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	out := map[string]interface{}{}
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+
+	gelf := map[string]interface{}{
+		"version":       "1.1",
+		"host":          l.host,
+		"short_message": "",
+		"timestamp":     float64(t.UnixNano()) / float64(time.Second),
+		"level":         l.gelfLevel(out[l.llfn]),
+	}
+	if msg, ok := out[l.mfn]; ok {
+		gelf["short_message"] = msg
+	}
+
+	delete(out, l.llfn)
+	delete(out, l.mfn)
+	delete(out, l.tfn)
+	for k, v := range out {
+		addGELFField(gelf, "_"+k, v)
+	}
+
+	// _id is reserved in GELF.
+	if id, ok := gelf["_id"]; ok {
+		delete(gelf, "_id")
+		gelf["__id"] = id
+	}
+
+	return json.Marshal(gelf)
+}
+
+// addGELFField will add v as key to gelf. Maps are flattened
+// and arrays are added as JSON strings since GELF only allows
+// strings and numbers as additional fields.
+func addGELFField(gelf map[string]interface{}, key string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, nv := range v {
+			addGELFField(gelf, key+"_"+k, nv)
+		}
+
+	case []interface{}:
+		raw, _ := json.Marshal(v)
+		gelf[key] = string(raw)
+
+	case bool:
+		if v {
+			gelf[key] = 1
+		} else {
+			gelf[key] = 0
+		}
+
+	// GELF has no null value so leave it out.
+	case nil:
+
+	default:
+		gelf[key] = v
+	}
+}
+
+// gelfLevel will return the GELF level for the log level
+// level. The warning and critical log levels of l are mapped
+// to warning and error. Unknown levels are mapped to info.
+// Returns int.
+func (l *Client) gelfLevel(level interface{}) int {
+	str, _ := level.(string)
+	switch str {
+	case l.wm:
+		return gelfLevels["warning"]
+
+	case l.cm:
+		return gelfLevels["error"]
+	}
+
+	if lvl, ok := gelfLevels[str]; ok {
+		return lvl
+	}
+
+	return gelfLevels["info"]
+}
+
+// hostname will return the hostname of the machine
+// or "unknown" if it can't be determined.
+// Returns string.
+func hostname() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "unknown"
+	}
+
+	return host
+}
@@ -0,0 +1,57 @@
+package llogger
+
+import (
+	"testing"
+	"time"
+)
+
+// TestGELF will test that messages are printed as GELF 1.1
+// when llogger-format is gelf.
+func TestGELF(t *testing.T) {
+	client := Create(nil, Input{"llogger-format": "gelf", "service": "llogger-test"})
+	start := float64(time.Now().UnixNano()) / float64(time.Second)
+
+	strs := capture(t, func() {
+		client.Print(Input{"loglevel": "warning", "message": "Testmessage1", "id": 1, "tags": []string{"a"}})
+		client.Error("Testmessage2", nil)
+	})
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	ts, _ := msg1["timestamp"].(float64)
+
+	switch {
+	case msg1["version"] != "1.1":
+		t.Fatalf("Expected version to be 1.1 but got %v", msg1["version"])
+
+	case msg1["host"] == nil || msg1["host"] == "":
+		t.Fatalf("Expected host to be set but got %v", msg1["host"])
+
+	case msg1["short_message"] != "Testmessage1":
+		t.Fatalf("Expected short_message to be Testmessage1 but got %v", msg1["short_message"])
+
+	case msg1["level"] != float64(4):
+		t.Fatalf("Expected level to be 4 but got %v", msg1["level"])
+
+	case msg2["level"] != float64(3):
+		t.Fatalf("Expected level for error to be 3 but got %v", msg2["level"])
+
+	case ts < start || ts > float64(time.Now().UnixNano())/float64(time.Second):
+		t.Fatalf("Expected timestamp to be seconds since epoch but got %v", msg1["timestamp"])
+
+	case msg1["_service"] != "llogger-test":
+		t.Fatalf("Expected _service to be llogger-test but got %v", msg1["_service"])
+
+	case msg1["_resource_function"] == nil || msg1["_resource_file"] == nil || msg1["_resource_row"] == nil:
+		t.Fatalf("Expected resource to be flattened to _resource_* but got %s", strs[0])
+
+	case msg1["_tags"] != `["a"]`:
+		t.Fatalf("Expected _tags to be a JSON string but got %v", msg1["_tags"])
+
+	case msg1["_id"] != nil || msg1["__id"] != float64(1):
+		t.Fatalf("Expected id to be added as __id since _id is reserved but got %s", strs[0])
+
+	case msg1["message"] != nil || msg1["loglevel"] != nil || msg1["time"] != nil || msg1["service"] != nil:
+		t.Fatalf("Expected no unprefixed fields but got %s", strs[0])
+	}
+}
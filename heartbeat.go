@@ -0,0 +1,57 @@
+package llogger
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Heartbeat takes interval and msg and starts a goroutine that prints
+// msg with log level info every interval, together with the seconds
+// elapsed since Heartbeat was called in the elapsed field. The caller
+// of Heartbeat is used as resource. The goroutine exits when stop is
+// called or when the context of the client is done. stop waits for the
+// goroutine to exit and can be called more than once. If interval
+// isn't positive nothing is started and stop does nothing.
+// Returns func().
+func (l *Client) Heartbeat(interval time.Duration, msg string) (stop func()) {
+	l = l.orDefault()
+	if interval <= 0 {
+		return func() {}
+	}
+	pc, _, _, _ := runtime.Caller(1)
+	start := time.Now()
+	quit := make(chan struct{})
+	done := make(chan struct{})
+
+	var ctxDone <-chan struct{}
+	if l.context != nil {
+		ctxDone = l.context.Done()
+	}
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				l.print(call{pc: pc}, l.leveled("info", msg, Input{"elapsed": now.Sub(start).Seconds()}))
+
+			case <-ctxDone:
+				return
+
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(quit) })
+		<-done
+	}
+}
@@ -0,0 +1,87 @@
+package llogger
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHeartbeat will test that heartbeats are printed until
+// stop is called and that the goroutine exits.
+func TestHeartbeat(t *testing.T) {
+	client := Create(nil, nil)
+	goroutines := runtime.NumGoroutine()
+
+	var stop func()
+	strs := capture(t, func() {
+		stop = client.Heartbeat(10*time.Millisecond, "Still working")
+		time.Sleep(55 * time.Millisecond)
+		stop()
+		stop()
+	})
+
+	if len(strs) < 1 {
+		t.Fatalf("Expected at least 1 heartbeat but got %d", len(strs))
+	}
+
+	msg := decode(t, strs[0])
+	res, _ := msg["resource"].(map[string]interface{})
+	elapsed, _ := msg["elapsed"].(float64)
+
+	switch {
+	case msg["message"] != "Still working" || msg["loglevel"] != "info":
+		t.Fatalf("Expected heartbeat message with log level info but got %s", strs[0])
+
+	case elapsed <= 0:
+		t.Fatalf("Expected elapsed to be positive but got %v", msg["elapsed"])
+
+	case !strings.HasSuffix(res["file"].(string), "heartbeat_test.go"):
+		t.Fatalf("Expected resource file to be heartbeat_test.go but got %v", res["file"])
+
+	case runtime.NumGoroutine() > goroutines:
+		t.Fatalf("Expected heartbeat goroutine to exit but got %d goroutines, had %d", runtime.NumGoroutine(), goroutines)
+	}
+}
+
+// TestHeartbeatContext will test that the heartbeat goroutine
+// exits when the context of the client is done.
+func TestHeartbeatContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	client := Create(ctx, nil)
+
+	stop := client.Heartbeat(time.Hour, "Still working")
+	cancel()
+
+	exited := make(chan struct{})
+	go func() {
+		stop()
+		close(exited)
+	}()
+
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected heartbeat goroutine to exit when context is done")
+	}
+}
+
+// TestHeartbeatInterval will test that a heartbeat with an interval
+// that isn't positive does nothing instead of panicking.
+func TestHeartbeatInterval(t *testing.T) {
+	client := Create(nil, nil)
+
+	str := captureRaw(t, func() {
+		for _, interval := range []time.Duration{0, -time.Second} {
+			stop := client.Heartbeat(interval, "Still working")
+			time.Sleep(10 * time.Millisecond)
+			stop()
+			stop()
+		}
+	})
+
+	if str != "" {
+		t.Fatalf("Expected nothing to be printed but got %s", str)
+	}
+}
@@ -0,0 +1,74 @@
+package llogger
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// The histogram buckets grow by histogramGrowth from histogramMin, so
+// the percentiles are within 10% of the real value between 1ms and 15
+// minutes, the max execution time of a Lambda. The last bucket ends at
+// 1.1^144 ms, about 15.2 minutes, and also holds longer durations.
+const (
+	histogramMin     = time.Millisecond
+	histogramGrowth  = 1.1
+	histogramBuckets = 145
+)
+
+// histogram is a fixed size sketch of durations with logarithmic
+// buckets, so its memory use is bounded no matter how many durations
+// are added. It's safe for concurrent use.
+type histogram struct {
+	mu      sync.Mutex
+	buckets [histogramBuckets]uint64
+	total   uint64
+}
+
+// invocations is the histogram of the durations of the invocations
+// over the lifetime of the container.
+var invocations = &histogram{}
+
+// add will add d to h.
+func (h *histogram) add(d time.Duration) {
+	i := 0
+	if d > histogramMin {
+		i = int(math.Ceil(math.Log(float64(d)/float64(histogramMin)) / math.Log(histogramGrowth)))
+	}
+	if i >= histogramBuckets {
+		i = histogramBuckets - 1
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buckets[i]++
+	h.total++
+}
+
+// percentile will return the upper bound of the bucket holding the
+// percentile p, between 0 and 100, of the durations in h. Returns 0
+// if h is empty.
+// Returns time.Duration.
+func (h *histogram) percentile(p float64) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(p / 100 * float64(h.total)))
+	if rank == 0 {
+		rank = 1
+	}
+
+	var seen uint64
+	for i, n := range h.buckets {
+		if seen += n; seen >= rank {
+			return time.Duration(float64(histogramMin) * math.Pow(histogramGrowth, float64(i)))
+		}
+	}
+
+	return 0
+}
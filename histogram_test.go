@@ -0,0 +1,66 @@
+package llogger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHistogram will test that the percentiles of the histogram
+// are within 10% of the real percentiles.
+func TestHistogram(t *testing.T) {
+	h := &histogram{}
+	empty := h.percentile(50)
+	for i := 1; i <= 100; i++ {
+		h.add(time.Duration(i) * 10 * time.Millisecond)
+	}
+	h.add(time.Hour)
+
+	p50 := h.percentile(50)
+	p95 := h.percentile(95)
+	max := h.percentile(100)
+
+	switch {
+	case empty != 0:
+		t.Fatalf("Expected 0 for an empty histogram but got %s", empty)
+
+	case p50 < 500*time.Millisecond || p50 > 565*time.Millisecond:
+		t.Fatalf("Expected p50 around 500ms but got %s", p50)
+
+	case p95 < 950*time.Millisecond || p95 > 1060*time.Millisecond:
+		t.Fatalf("Expected p95 around 950ms but got %s", p95)
+
+	case max < 15*time.Minute:
+		t.Fatalf("Expected durations above the last bucket in the last bucket but got %s", max)
+	}
+}
+
+// TestPercentiles will test that the summary of each invocation
+// has the percentiles of the invocation durations.
+func TestPercentiles(t *testing.T) {
+	buf := &syncBuffer{}
+	for i := 1; i <= 5; i++ {
+		client := Create(nil, Input{"llogger-writer": buf, "llogger-percentiles": true})
+		time.Sleep(time.Duration(i) * time.Millisecond)
+		client.Close()
+	}
+
+	strs := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(strs) != 5 {
+		t.Fatalf("Expected 5 summaries but got %q", strs)
+	}
+
+	for _, str := range strs {
+		msg := decode(t, str)
+		p50, _ := msg["durationP50"].(float64)
+		p95, _ := msg["durationP95"].(float64)
+
+		switch {
+		case msg["message"] != "Summary":
+			t.Fatalf("Expected a summary but got %s", str)
+
+		case p50 <= 0 || p95 < p50:
+			t.Fatalf("Expected 0 < durationP50 <= durationP95 but got %s", str)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package llogger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// HTTPRequest prints an access log message for an HTTP request with
+// the method, path, status and latency in milliseconds. By default the
+// fields are flat, http.method, http.path, http.status and
+// http.latency_ms, and with llogger-http set to nested in the Input for
+// Create they are nested in an http field instead. The message defaults
+// to the method and path, and the log level to info, or the critical
+// log level if the status is 500 or above. Both can be set in inp.
+func (l *Client) HTTPRequest(method string, path string, status int, latency time.Duration, inp Input) {
+	l = l.orDefault()
+	data := Input{l.llfn: "info", l.mfn: method + " " + path}
+	if status >= 500 {
+		data[l.llfn] = l.cm
+	}
+	for k, v := range inp {
+		data[k] = v
+	}
+
+	latencyMs := float64(latency) / float64(time.Millisecond)
+	switch {
+	case l.httpNested:
+		data["http"] = Input{
+			"method":     method,
+			"path":       path,
+			"status":     status,
+			"latency_ms": latencyMs,
+		}
+
+	default:
+		data["http.method"] = method
+		data["http.path"] = path
+		data["http.status"] = status
+		data["http.latency_ms"] = latencyMs
+	}
+
+	l.print(call{skip: 2}, data)
+}
+
+// statusWriter is a http.ResponseWriter that captures the status
+// written by the handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader captures status and writes it to the wrapped
+// http.ResponseWriter.
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes b to the wrapped http.ResponseWriter, capturing
+// the implicit status 200 if no status has been written.
+// Returns int and error.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes the wrapped http.ResponseWriter if it's a
+// http.Flusher, so streaming responses work behind the middleware.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack hijacks the connection of the wrapped http.ResponseWriter
+// if it's a http.Hijacker, for example for websocket upgrades. The
+// status is captured as 101 Switching Protocols if none has been
+// written.
+// Returns net.Conn, *bufio.ReadWriter and error.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("llogger: the http.ResponseWriter isn't a http.Hijacker")
+	}
+
+	conn, rw, err := h.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, which is used
+// by http.ResponseController to find its optional interfaces.
+// Returns http.ResponseWriter.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// HTTPMiddleware returns a http.Handler that calls next and prints an
+// access log message with HTTPRequest for each request. Panics in next
+// are recovered and printed with the critical log level and the panic
+// field, and the status 500 is written if next hasn't written a status.
+// http.ErrAbortHandler is panicked again since it's used to abort the
+// response on purpose. The http.ResponseWriter passed to next forwards
+// http.Flusher and http.Hijacker.
+// Returns http.Handler.
+func (l *Client) HTTPMiddleware(next http.Handler) http.Handler {
+	l = l.orDefault()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+
+		defer func() {
+			var inp Input
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				if sw.status == 0 {
+					sw.WriteHeader(http.StatusInternalServerError)
+				}
+				inp = Input{l.llfn: l.cm, "panic": fmt.Sprint(rec)}
+			}
+			if sw.status == 0 {
+				sw.status = http.StatusOK
+			}
+
+			l.HTTPRequest(r.Method, r.URL.Path, sw.status, time.Since(start), inp)
+		}()
+
+		next.ServeHTTP(sw, r)
+	})
+}
@@ -0,0 +1,138 @@
+package llogger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHTTPRequest will test that HTTPRequest prints the standardized
+// fields, both flat and nested.
+func TestHTTPRequest(t *testing.T) {
+	client1 := Create(nil, nil)
+	client2 := Create(nil, Input{"llogger-http": "nested"})
+
+	strs := capture(t, func() {
+		client1.HTTPRequest("GET", "/items", 200, 1500*time.Microsecond, Input{"requestId": "1337"})
+		client1.HTTPRequest("POST", "/items", 502, time.Second, nil)
+		client2.HTTPRequest("GET", "/items", 404, 2*time.Millisecond, Input{"message": "Testmessage3"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+	http3, _ := msg3["http"].(map[string]interface{})
+
+	switch {
+	case msg1["http.method"] != "GET" || msg1["http.path"] != "/items" || msg1["http.status"] != float64(200) || msg1["http.latency_ms"] != 1.5:
+		t.Fatalf("Expected flat http fields in msg1 but got %s", strs[0])
+
+	case msg1["message"] != "GET /items" || msg1["loglevel"] != "info" || msg1["requestId"] != "1337":
+		t.Fatalf("Expected default message and log level in msg1 but got %s", strs[0])
+
+	case msg2["loglevel"] != "error" || msg2["http.latency_ms"] != float64(1000):
+		t.Fatalf("Expected critical log level for status 502 in msg2 but got %s", strs[1])
+
+	case http3 == nil || http3["method"] != "GET" || http3["status"] != float64(404) || http3["latency_ms"] != float64(2):
+		t.Fatalf("Expected nested http fields in msg3 but got %s", strs[2])
+
+	case msg3["http.method"] != nil || msg3["message"] != "Testmessage3":
+		t.Fatalf("Expected no flat http fields and the message of inp in msg3 but got %s", strs[2])
+	}
+}
+
+// TestHTTPMiddleware will test that requests through the middleware
+// are printed with the captured status and that panics are recovered.
+func TestHTTPMiddleware(t *testing.T) {
+	client := Create(nil, nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/created", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("Testpanic")
+	})
+	handler := client.HTTPMiddleware(mux)
+
+	codes := []int{}
+	strs := capture(t, func() {
+		for _, path := range []string{"/created", "/ok", "/panic"} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("POST", path, nil))
+			codes = append(codes, rec.Code)
+		}
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	switch {
+	case msg1["http.method"] != "POST" || msg1["http.path"] != "/created" || msg1["http.status"] != float64(201) || codes[0] != 201:
+		t.Fatalf("Expected status 201 in msg1 but got %s", strs[0])
+
+	case msg2["http.status"] != float64(200) || msg2["loglevel"] != "info" || codes[1] != 200:
+		t.Fatalf("Expected implicit status 200 in msg2 but got %s", strs[1])
+
+	case msg3["http.status"] != float64(500) || msg3["loglevel"] != "error" || msg3["panic"] != "Testpanic" || codes[2] != 500:
+		t.Fatalf("Expected recovered panic with status 500 in msg3 but got %s", strs[2])
+
+	case msg1["http.latency_ms"] == nil:
+		t.Fatalf("Expected http.latency_ms in msg1 but got %s", strs[0])
+	}
+}
+
+// TestHTTPMiddlewareWriter will test that the http.ResponseWriter
+// passed to the handler forwards http.Flusher and http.Hijacker
+// and that http.ErrAbortHandler is panicked again.
+func TestHTTPMiddlewareWriter(t *testing.T) {
+	client := Create(nil, nil)
+	mux := http.NewServeMux()
+	hijackErr := error(nil)
+	controllerErr := error(nil)
+	mux.HandleFunc("/flush", func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		_, _, hijackErr = w.(http.Hijacker).Hijack()
+	})
+	mux.HandleFunc("/controller", func(w http.ResponseWriter, r *http.Request) {
+		controllerErr = http.NewResponseController(w).Flush()
+	})
+	mux.HandleFunc("/abort", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+	handler := client.HTTPMiddleware(mux)
+
+	flushed := []bool{}
+	rec := interface{}(nil)
+	strs := capture(t, func() {
+		for _, path := range []string{"/flush", "/controller"} {
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, httptest.NewRequest("GET", path, nil))
+			flushed = append(flushed, resp.Flushed)
+		}
+		func() {
+			defer func() { rec = recover() }()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+		}()
+	})
+	msg1 := decode(t, strs[0])
+
+	switch {
+	case !flushed[0] || msg1["http.status"] != float64(200):
+		t.Fatalf("Expected flushed response with status 200 but got %v and %s", flushed[0], strs[0])
+
+	case hijackErr == nil:
+		t.Fatalf("Expected error from Hijack on a recorder but got nil")
+
+	case !flushed[1] || controllerErr != nil:
+		t.Fatalf("Expected flushed response through http.ResponseController but got %v and %v", flushed[1], controllerErr)
+
+	case rec != http.ErrAbortHandler:
+		t.Fatalf("Expected http.ErrAbortHandler to be panicked again but got %v", rec)
+
+	case len(strs) != 2:
+		t.Fatalf("Expected 2 messages but got %d", len(strs))
+	}
+}
@@ -0,0 +1,79 @@
+package llogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// jsonWriter is an io.WriteCloser that prints each JSON line written
+// to it as a message using client.
+type jsonWriter struct {
+	client *Client
+	mu     sync.Mutex
+	buf    []byte
+}
+
+// JSONWriter returns an io.WriteCloser for ingesting the output of a
+// subprocess that already prints JSON lines. Each line written is parsed
+// as a JSON object and printed as a message with the fields of the client
+// and the built in fields added, in the format of the client. Lines that
+// aren't JSON objects are printed with the line as message instead of
+// being dropped. Empty lines are skipped. Writes don't need to be whole
+// lines, a partial line is buffered until the rest is written or the
+// writer is closed. The caller of Write is used as resource.
+// Returns io.WriteCloser.
+func (l *Client) JSONWriter() io.WriteCloser {
+	l = l.orDefault()
+	return &jsonWriter{client: l}
+}
+
+// Write prints all complete lines in p, buffering any partial line.
+// Returns int and error.
+func (w *jsonWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.line(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Close prints the buffered partial line, if any.
+// Returns error.
+func (w *jsonWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.line(w.buf)
+	w.buf = nil
+	return nil
+}
+
+// line will print line as a message. Numbers are kept as json.Number
+// so that large integers aren't rounded.
+func (w *jsonWriter) line(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+
+	var inp Input
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&inp); err != nil || inp == nil || dec.More() {
+		inp = Input{w.client.mfn: string(line)}
+	}
+
+	// Skip line and Write or Close.
+	w.client.print(call{skip: 3}, inp)
+}
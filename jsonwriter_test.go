@@ -0,0 +1,50 @@
+package llogger
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestJSONWriter will test that JSON lines written to JSONWriter are
+// printed with the fields of the client and that other lines are
+// printed as messages.
+func TestJSONWriter(t *testing.T) {
+	client := Create(nil, Input{"service": "llogger-test"})
+
+	strs := capture(t, func() {
+		w := client.JSONWriter()
+		w.Write([]byte(`{"loglevel":"warning","message":"Testmessage1","id":9007199254740993}` + "\n" + "Testmes"))
+		w.Write([]byte("sage2\n\n[1,2]\n"))
+		w.Write([]byte(`{"message":"Testmessage4"}`))
+		w.Close()
+	})
+
+	if len(strs) != 4 {
+		t.Fatalf("Expected 4 lines from stdout but got %q", strs)
+	}
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+	msg4 := decode(t, strs[3])
+	res1, _ := msg1["resource"].(map[string]interface{})
+
+	switch {
+	case msg1["loglevel"] != "warning" || msg1["message"] != "Testmessage1" || msg1["service"] != "llogger-test":
+		t.Fatalf("Expected the fields of the line and the client in msg1 but got %s", strs[0])
+
+	case !strings.Contains(strs[0], `"id":9007199254740993`):
+		t.Fatalf("Expected the id to not be rounded in msg1 but got %s", strs[0])
+
+	case msg1["time"] == nil || res1 == nil || !strings.HasSuffix(res1["file"].(string), "jsonwriter_test.go"):
+		t.Fatalf("Expected time and the resource of the caller of Write in msg1 but got %s", strs[0])
+
+	case msg2["message"] != "Testmessage2" || msg2["service"] != "llogger-test":
+		t.Fatalf("Expected the plain text line as message in msg2 but got %s", strs[1])
+
+	case msg3["message"] != "[1,2]":
+		t.Fatalf("Expected a JSON line that isn't an object as message in msg3 but got %s", strs[2])
+
+	case msg4["message"] != "Testmessage4":
+		t.Fatalf("Expected the partial line to be printed by Close in msg4 but got %s", strs[3])
+	}
+}
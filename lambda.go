@@ -0,0 +1,96 @@
+package llogger
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// maxContextDepth is the max number of parent contexts
+// searched for the Lambda context.
+const maxContextDepth = 64
+
+// invokedFunctionArn will return the InvokedFunctionArn of the Lambda
+// context in ctx, or an empty string if ctx isn't a Lambda context. The
+// Lambda context is stored under an unexported key by the Lambda Go
+// runtime, so instead of depending on it the values of ctx and its
+// parents are searched for a pointer to a struct with an
+// InvokedFunctionArn string field.
+// Returns string.
+func invokedFunctionArn(ctx context.Context) string {
+	v := reflect.ValueOf(ctx)
+	for i := 0; i < maxContextDepth && v.IsValid(); i++ {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			v = v.Elem()
+			continue
+
+		case reflect.Struct:
+		default:
+			return ""
+		}
+
+		// Contexts created with context.WithValue have the value in val.
+		if val := v.FieldByName("val"); val.IsValid() {
+			if arn := arnField(val); arn != "" {
+				return arn
+			}
+		}
+
+		// The parent context is in the Context field.
+		v = v.FieldByName("Context")
+	}
+
+	return ""
+}
+
+// arnField will return the InvokedFunctionArn field of v if v is a
+// pointer to a struct with such a string field, or an empty string.
+// Returns string.
+func arnField(v reflect.Value) string {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+
+	arn := v.Elem().FieldByName("InvokedFunctionArn")
+	if !arn.IsValid() || arn.Kind() != reflect.String {
+		return ""
+	}
+
+	return arn.String()
+}
+
+// arnAlias will return the alias of the invoked function ARN arn, such
+// as prod in arn:aws:lambda:eu-west-1:123456789012:function:name:prod,
+// or an empty string if arn has no qualifier or is qualified with a
+// version or $LATEST rather than an alias.
+// Returns string.
+func arnAlias(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) != 8 || parts[5] != "function" {
+		return ""
+	}
+
+	alias := parts[7]
+	if alias == "$LATEST" || strings.IndexFunc(alias, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		return ""
+	}
+
+	return alias
+}
+
+// stage will return the stage of the invocation, which is the alias of
+// the invoked function ARN if there is one, or the value of the stage
+// environment variable read at Create.
+// Returns string.
+func (l *Client) stage() string {
+	if alias := arnAlias(l.arn); alias != "" {
+		return alias
+	}
+
+	return l.stageEnv
+}
@@ -0,0 +1,131 @@
+package llogger
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// lambdaContext mimics the LambdaContext of the Lambda Go runtime.
+type lambdaContext struct {
+	AwsRequestID       string
+	InvokedFunctionArn string
+}
+
+// lambdaKey mimics the unexported context key of the Lambda Go runtime.
+type lambdaKey struct{}
+
+// TestFunctionArn will test that the invoked function ARN is read
+// from a Lambda context when enabled.
+func TestFunctionArn(t *testing.T) {
+	arn := "arn:aws:lambda:eu-west-1:123456789012:function:test:prod"
+	ctx := context.WithValue(context.Background(), lambdaKey{}, &lambdaContext{AwsRequestID: "1337", InvokedFunctionArn: arn})
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	plain, cancelPlain := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelPlain()
+
+	client1 := Create(ctx, Input{"llogger-arn": true})
+	client2 := Create(ctx, Input{"llogger-arn": true, "llogger-afn": "arn"})
+	client3 := Create(plain, Input{"llogger-arn": true})
+	client4 := Create(ctx, nil)
+	defer client1.Close()
+	defer client2.Close()
+	defer client3.Close()
+	defer client4.Close()
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+		client3.Print(Input{"message": "Testmessage3"})
+		client4.Print(Input{"message": "Testmessage4"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+	msg4 := decode(t, strs[3])
+
+	switch {
+	case msg1["functionArn"] != arn:
+		t.Fatalf("Expected functionArn %s in msg1 but got %s", arn, strs[0])
+
+	case msg2["arn"] != arn || msg2["functionArn"] != nil:
+		t.Fatalf("Expected arn %s in msg2 but got %s", arn, strs[1])
+
+	case msg3["functionArn"] != nil:
+		t.Fatalf("Expected no functionArn without a Lambda context in msg3 but got %s", strs[2])
+
+	case msg4["functionArn"] != nil:
+		t.Fatalf("Expected no functionArn when not enabled in msg4 but got %s", strs[3])
+	}
+}
+
+// TestArnAlias will test extracting the alias from invoked
+// function ARNs with and without qualifiers.
+func TestArnAlias(t *testing.T) {
+	switch {
+	case arnAlias("arn:aws:lambda:eu-west-1:123456789012:function:test:prod") != "prod":
+		t.Fatalf("Expected alias prod")
+
+	case arnAlias("arn:aws:lambda:eu-west-1:123456789012:function:test") != "":
+		t.Fatalf("Expected no alias for an unqualified ARN")
+
+	case arnAlias("arn:aws:lambda:eu-west-1:123456789012:function:test:42") != "":
+		t.Fatalf("Expected no alias for a version")
+
+	case arnAlias("arn:aws:lambda:eu-west-1:123456789012:function:test:$LATEST") != "":
+		t.Fatalf("Expected no alias for $LATEST")
+	}
+}
+
+// TestStage will test that the stage is taken from the alias of the
+// invoked function ARN before the environment.
+func TestStage(t *testing.T) {
+	os.Setenv("STAGE", "dev")
+	defer os.Unsetenv("STAGE")
+	os.Setenv("DEPLOY_ENV", "test")
+	defer os.Unsetenv("DEPLOY_ENV")
+
+	lambda := func(arn string) (context.Context, context.CancelFunc) {
+		ctx := context.WithValue(context.Background(), lambdaKey{}, &lambdaContext{InvokedFunctionArn: arn})
+		return context.WithTimeout(ctx, time.Minute)
+	}
+	ctx1, cancel1 := lambda("arn:aws:lambda:eu-west-1:123456789012:function:test:prod")
+	defer cancel1()
+	ctx2, cancel2 := lambda("arn:aws:lambda:eu-west-1:123456789012:function:test:$LATEST")
+	defer cancel2()
+
+	client1 := Create(ctx1, Input{"llogger-stage": true})
+	client2 := Create(ctx2, Input{"llogger-stage": true})
+	client3 := Create(nil, Input{"llogger-stage": true, "llogger-stfn": "env", "llogger-stageenv": "DEPLOY_ENV"})
+	client4 := Create(ctx1, nil)
+	defer client1.Close()
+	defer client2.Close()
+	defer client4.Close()
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+		client3.Print(Input{"message": "Testmessage3"})
+		client4.Print(Input{"message": "Testmessage4"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+	msg4 := decode(t, strs[3])
+
+	switch {
+	case msg1["stage"] != "prod":
+		t.Fatalf("Expected stage prod from the alias in msg1 but got %s", strs[0])
+
+	case msg2["stage"] != "dev":
+		t.Fatalf("Expected stage dev from the environment in msg2 but got %s", strs[1])
+
+	case msg3["env"] != "test" || msg3["stage"] != nil:
+		t.Fatalf("Expected env test from DEPLOY_ENV in msg3 but got %s", strs[2])
+
+	case msg4["stage"] != nil:
+		t.Fatalf("Expected no stage when not enabled in msg4 but got %s", strs[3])
+	}
+}
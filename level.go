@@ -0,0 +1,117 @@
+package llogger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// levels will return the log levels of l in order of increasing
+// severity. These are the custom log levels set with llogger-levels
+// or debug, info and the warning and critical log levels.
+// Returns []string.
+func (l *Client) levels() []string {
+	if len(l.customLevels) > 0 {
+		return l.customLevels
+	}
+
+	return []string{"debug", "info", l.wm, l.cm}
+}
+
+// setLevels will set the custom log levels from llogger-levels and the
+// minimum log level from llogger-minlevel in l.data. The custom log
+// levels can be a []string or a comma separated string. If they contain
+// duplicates or empty names they are ignored and an error is returned.
+// An unknown minimum log level is ignored.
+// Returns error.
+func (l *Client) setLevels() error {
+	var err error
+	if levels, ok := l.data["llogger-levels"]; ok {
+		delete(l.data, "llogger-levels")
+
+		var names []string
+		switch levels := levels.(type) {
+		case []string:
+			names = levels
+
+		case string:
+			names = strings.Split(levels, ",")
+			for i := range names {
+				names[i] = strings.TrimSpace(names[i])
+			}
+		}
+
+		seen := map[string]bool{}
+		for _, name := range names {
+			if name == "" || seen[name] {
+				err = fmt.Errorf("Custom log levels ignored, %q is empty or duplicated", name)
+				names = nil
+				break
+			}
+			seen[name] = true
+		}
+		l.customLevels = names
+	}
+
+	if level, ok := l.configString("llogger-pretty"); ok {
+		l.pretty = level
+	}
+
+	if level, ok := l.configString("llogger-minlevel"); ok {
+		l.minLevel = -1
+		if severity, ok := l.Severity(level); ok {
+			l.minLevel = severity
+		}
+	}
+
+	return err
+}
+
+// Severity returns the severity of the log level level, which is its
+// position in the log levels starting at 0 for the least severe. The log
+// levels are the custom log levels set with llogger-levels or debug,
+// info and the warning and critical log levels. ok is false if level is
+// unknown.
+// Returns int and bool.
+func (l *Client) Severity(level string) (int, bool) {
+	l = l.orDefault()
+	for i, name := range l.levels() {
+		if name == level {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// enabled will return if a message with log level level should be
+// printed according to the minimum log level. Messages without a log
+// level or with an unknown log level are always printed.
+// Returns bool.
+func (l *Client) enabled(level interface{}) bool {
+	if l.minLevel <= 0 {
+		return true
+	}
+
+	str, _ := level.(string)
+	if severity, ok := l.Severity(str); ok {
+		return severity >= l.minLevel
+	}
+
+	return true
+}
+
+// indented will return if a message with log level level should be
+// printed as indented JSON, which is when its log level is at or below
+// the log level set with llogger-pretty. Messages without a log level
+// or with an unknown log level are printed compact.
+// Returns bool.
+func (l *Client) indented(level interface{}) bool {
+	if l.pretty == "" || l.format != "json" {
+		return false
+	}
+
+	str, _ := level.(string)
+	severity, ok := l.Severity(str)
+	max, known := l.Severity(l.pretty)
+	return ok && known && severity <= max
+}
@@ -0,0 +1,106 @@
+package llogger
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMinLevel will test that messages below the minimum log
+// level are dropped.
+func TestMinLevel(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-minlevel": "warning"})
+	client2 := Create(nil, Input{"llogger-minlevel": "unknown"})
+
+	strs1 := capture(t, func() {
+		client1.Debug("Testmessage1", nil)
+		client1.Info("Testmessage2", nil)
+		client1.Warn("Testmessage3", nil)
+		client1.Error("Testmessage4", nil)
+		client1.Print(Input{"message": "Testmessage5"})
+		client1.Log("notice", "Testmessage6", nil)
+	})
+	strs2 := capture(t, func() {
+		client2.Debug("Testmessage7", nil)
+	})
+
+	switch {
+	case len(strs1) != 4:
+		t.Fatalf("Expected 4 messages from client1 but got %q", strs1)
+
+	case decode(t, strs1[0])["message"] != "Testmessage3" || decode(t, strs1[1])["message"] != "Testmessage4":
+		t.Fatalf("Expected the warning and error messages first but got %q", strs1)
+
+	case decode(t, strs1[2])["message"] != "Testmessage5" || decode(t, strs1[3])["message"] != "Testmessage6":
+		t.Fatalf("Expected messages without or with unknown log level to be printed but got %q", strs1)
+
+	case len(strs2) != 1:
+		t.Fatalf("Expected an unknown minimum log level to be ignored but got %q", strs2)
+	}
+}
+
+// TestCustomLevels will test that the minimum log level and
+// Severity use the order of the custom log levels.
+func TestCustomLevels(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-levels": []string{"trace", "debug", "info", "notice", "warning", "error", "fatal"}, "llogger-minlevel": "notice"})
+	client2 := Create(nil, Input{"llogger-levels": "trace, debug, fatal", "llogger-minlevel": "debug"})
+
+	strs1 := capture(t, func() {
+		client1.Log("trace", "Testmessage1", nil)
+		client1.Info("Testmessage2", nil)
+		client1.Log("notice", "Testmessage3", nil)
+		client1.Log("fatal", "Testmessage4", nil)
+		client2.Log("trace", "Testmessage5", nil)
+		client2.Log("fatal", "Testmessage6", nil)
+	})
+
+	// Create prints the warning about the ignored log levels.
+	var client3 *Client
+	strs2 := capture(t, func() {
+		client3 = Create(nil, Input{"llogger-levels": []string{"info", "notice", "info"}, "llogger-minlevel": "warning"})
+		client3.Info("Testmessage7", nil)
+	})
+
+	severity, ok := client1.Severity("fatal")
+
+	switch {
+	case len(strs1) != 3:
+		t.Fatalf("Expected 3 messages but got %q", strs1)
+
+	case decode(t, strs1[0])["message"] != "Testmessage3" || decode(t, strs1[1])["message"] != "Testmessage4":
+		t.Fatalf("Expected notice and fatal messages from client1 but got %q", strs1)
+
+	case decode(t, strs1[2])["message"] != "Testmessage6":
+		t.Fatalf("Expected only the fatal message from client2 but got %q", strs1)
+
+	case severity != 6 || !ok:
+		t.Fatalf("Expected fatal to have severity 6 but got %d", severity)
+
+	case len(strs2) != 1 || !strings.Contains(strs2[0], "Custom log levels ignored"):
+		t.Fatalf("Expected a warning about duplicated log levels but got %q", strs2)
+	}
+}
+
+// TestPretty will test that messages at or below the pretty log
+// level are indented while other messages are compact.
+func TestPretty(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-pretty": "debug"})
+	client2 := Create(nil, Input{"llogger-pretty": "debug", "llogger-format": "flat"})
+
+	raw := captureRaw(t, func() {
+		client1.Debug("Testmessage1", nil)
+		client1.Info("Testmessage2", nil)
+		client2.Debug("Testmessage3", nil)
+	})
+	lines := strings.Split(strings.TrimSuffix(raw, "\n"), "\n")
+
+	switch {
+	case len(lines) < 4 || lines[0] != "{" || !strings.HasPrefix(lines[1], `  "`):
+		t.Fatalf("Expected the debug message to be indented but got %q", raw)
+
+	case !strings.Contains(raw, "\n}\n{\"loglevel\":\"info\",\"message\":\"Testmessage2\""):
+		t.Fatalf("Expected the info message to be compact but got %q", raw)
+
+	case !strings.HasPrefix(lines[len(lines)-1], "{") || !strings.Contains(lines[len(lines)-1], "Testmessage3"):
+		t.Fatalf("Expected the debug message to be compact with format flat but got %q", raw)
+	}
+}
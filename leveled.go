@@ -0,0 +1,193 @@
+package llogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Leveled is implemented by loggers with one method per log level.
+// *Client satisfies Leveled so it can be passed where such a logger
+// is expected.
+type Leveled interface {
+	Debug(msg string, inp Input)
+	Info(msg string, inp Input)
+	Warn(msg string, inp Input)
+	Error(msg string, inp Input)
+}
+
+// Debug prints msg and inp with log level debug.
+func (l *Client) Debug(msg string, inp Input) {
+	l = l.orDefault()
+	l.print(call{skip: 2}, l.leveled("debug", msg, inp))
+}
+
+// Info prints msg and inp with log level info.
+func (l *Client) Info(msg string, inp Input) {
+	l = l.orDefault()
+	l.print(call{skip: 2}, l.leveled("info", msg, inp))
+}
+
+// Warn prints msg and inp with the warning log level
+// set by llogger-wm.
+func (l *Client) Warn(msg string, inp Input) {
+	l = l.orDefault()
+	l.print(call{skip: 2}, l.leveled(l.wm, msg, inp))
+}
+
+// warnedKeys is the set of keys that WarnOnce has printed a
+// warning for. It's shared by all clients in the process.
+var warnedKeys sync.Map
+
+// WarnOnce prints msg and inp with the warning log level set by
+// llogger-wm, but only the first time it's called with key in the
+// process. It's meant for deprecation notices and configuration
+// warnings and it's safe to call from several goroutines.
+func (l *Client) WarnOnce(key string, msg string, inp Input) {
+	l = l.orDefault()
+	if _, seen := warnedKeys.LoadOrStore(key, struct{}{}); seen {
+		return
+	}
+	l.print(call{skip: 2}, l.leveled(l.wm, msg, inp))
+}
+
+// Error prints msg and inp with the critical log level
+// set by llogger-cm.
+func (l *Client) Error(msg string, inp Input) {
+	l = l.orDefault()
+	l.print(call{skip: 2}, l.leveled(l.cm, msg, inp))
+}
+
+// leveled will return a copy of inp with the log level
+// and message fields set to level and msg.
+// Returns Input.
+func (l *Client) leveled(level string, msg string, inp Input) Input {
+	data := Input{}
+	for k, v := range inp {
+		data[k] = v
+	}
+	data[l.llfn] = level
+	data[l.mfn] = msg
+
+	return data
+}
+
+// leveledWriter is a Leveled that writes level prefixed
+// lines to w.
+type leveledWriter struct {
+	w io.Writer
+}
+
+// NewLeveledWriter takes w and returns a Leveled that writes each
+// message as a line to w prefixed with the upper case log level,
+// followed by the message and inp as JSON if it's not empty.
+// For example "WARN Flux capacitor is hot {"temp":88}".
+// Returns Leveled.
+func NewLeveledWriter(w io.Writer) Leveled {
+	return &leveledWriter{w: w}
+}
+
+// Debug writes msg and inp prefixed with DEBUG.
+func (lw *leveledWriter) Debug(msg string, inp Input) {
+	lw.write("debug", msg, inp)
+}
+
+// Info writes msg and inp prefixed with INFO.
+func (lw *leveledWriter) Info(msg string, inp Input) {
+	lw.write("info", msg, inp)
+}
+
+// Warn writes msg and inp prefixed with WARN.
+func (lw *leveledWriter) Warn(msg string, inp Input) {
+	lw.write("warn", msg, inp)
+}
+
+// Error writes msg and inp prefixed with ERROR.
+func (lw *leveledWriter) Error(msg string, inp Input) {
+	lw.write("error", msg, inp)
+}
+
+// write will write level, msg and inp as a line to lw.w.
+// If inp can't be JSON marshaled it's left out.
+func (lw *leveledWriter) write(level string, msg string, inp Input) {
+	line := strings.ToUpper(level) + " " + msg
+	if len(inp) > 0 {
+		if raw, err := json.Marshal(inp); err == nil {
+			line += " " + string(raw)
+		}
+	}
+
+	fmt.Fprintln(lw.w, line)
+}
+
+// LogError takes err and inp and prints err with the critical log
+// level set by llogger-cm. The error message is used as message,
+// unless inp already has a message in which case it's added in the
+// error field. Nothing is printed if err is nil or a typed nil.
+func (l *Client) LogError(err error, inp Input) {
+	l = l.orDefault()
+	message := errorMessage(err)
+	if message == nil {
+		return
+	}
+
+	data := Input{}
+	for k, v := range inp {
+		data[k] = v
+	}
+	data[l.llfn] = l.cm
+
+	if _, ok := data[l.mfn]; ok {
+		data["error"] = message
+	} else {
+		data[l.mfn] = message
+	}
+
+	l.print(call{skip: 2}, data)
+}
+
+// WrapError takes err and msg and prints msg with the critical log
+// level set by llogger-cm and err in the error field. The returned
+// error wraps err with msg, or is err as is if msg is empty, so it
+// can be returned directly. Nothing is printed and err is returned
+// as is if err is nil or a typed nil.
+// Returns error.
+func (l *Client) WrapError(err error, msg string) error {
+	l = l.orDefault()
+	message := errorMessage(err)
+	if message == nil {
+		return err
+	}
+
+	data := Input{l.llfn: l.cm, "error": message}
+	if msg == "" {
+		data[l.mfn] = message
+		l.print(call{skip: 2}, data)
+		return err
+	}
+	data[l.mfn] = msg
+
+	l.print(call{skip: 2}, data)
+	return &wrappedError{msg: fmt.Sprintf("%s: %v", msg, message), err: err}
+}
+
+// wrappedError is the error returned by WrapError. It has the Unwrap
+// method so that errors.Is and errors.As find the wrapped error.
+type wrappedError struct {
+	msg string
+	err error
+}
+
+// Error returns the message of the error and the wrapped error.
+// Returns string.
+func (e *wrappedError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the wrapped error.
+// Returns error.
+func (e *wrappedError) Unwrap() error {
+	return e.err
+}
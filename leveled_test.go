@@ -0,0 +1,207 @@
+package llogger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Make sure that *Client satisfies Leveled.
+var _ Leveled = &Client{}
+
+// TestLeveled will test the leveled print methods of *Client.
+func TestLeveled(t *testing.T) {
+	var client Leveled = Create(nil, Input{"llogger-wm": "custom-warning", "llogger-cm": "custom-error"})
+
+	strs := capture(t, func() {
+		client.Debug("Testmessage1", nil)
+		client.Info("Testmessage2", Input{"extra": "extra test data"})
+		client.Warn("Testmessage3", nil)
+		client.Error("Testmessage4", Input{"loglevel": "ignored"})
+	})
+
+	if len(strs) != 4 {
+		t.Fatalf("Expected 4 lines from stdout but got %d", len(strs))
+	}
+
+	levels := []string{"debug", "info", "custom-warning", "custom-error"}
+	for i, str := range strs {
+		msg := decode(t, str)
+		res, _ := msg["resource"].(map[string]interface{})
+
+		switch {
+		case msg["loglevel"] != levels[i]:
+			t.Fatalf("Expected loglevel in msg%d to be %s but got %v", i+1, levels[i], msg["loglevel"])
+
+		case !strings.HasSuffix(res["file"].(string), "leveled_test.go"):
+			t.Fatalf("Expected resource file in msg%d to be leveled_test.go but got %v", i+1, res["file"])
+		}
+	}
+
+	if msg := decode(t, strs[1]); msg["extra"] != "extra test data" || msg["message"] != "Testmessage2" {
+		t.Fatalf("Expected message and extra in msg2 but got %s", strs[1])
+	}
+}
+
+// TestLeveledWriter will test that NewLeveledWriter writes
+// level prefixed lines.
+func TestLeveledWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewLeveledWriter(buf)
+
+	w.Debug("Testmessage1", nil)
+	w.Info("Testmessage2", nil)
+	w.Warn("Testmessage3", Input{"temp": 88})
+	w.Error("Testmessage4", nil)
+
+	expected := "DEBUG Testmessage1\nINFO Testmessage2\nWARN Testmessage3 {\"temp\":88}\nERROR Testmessage4\n"
+	if buf.String() != expected {
+		t.Fatalf("Expected leveled writer output %q but got %q", expected, buf.String())
+	}
+}
+
+// TestLevel will test that the pinned log level is used and
+// that it can be overridden per message.
+func TestLevel(t *testing.T) {
+	client := Create(nil, nil)
+	debug := client.Level("debug").WithFields(Input{"block": "init"})
+
+	strs := capture(t, func() {
+		debug.Print(Input{"message": "Testmessage1"})
+		debug.Printf("Testmessage%d", 2)
+		debug.Print(Input{"loglevel": "info", "message": "Testmessage3"})
+		debug.Error("Testmessage4", nil)
+		client.Printf("Testmessage%d", 5)
+	})
+
+	expected := []interface{}{"debug", "debug", "info", "error", nil}
+	for i, str := range strs {
+		msg := decode(t, str)
+		switch {
+		case msg["loglevel"] != expected[i]:
+			t.Fatalf("Expected loglevel in msg%d to be %v but got %v", i+1, expected[i], msg["loglevel"])
+
+		case msg["message"] != fmt.Sprintf("Testmessage%d", i+1):
+			t.Fatalf("Expected message in msg%d to be Testmessage%d but got %v", i+1, i+1, msg["message"])
+		}
+	}
+
+	if msg := decode(t, strs[1]); msg["block"] != "init" {
+		t.Fatalf("Expected block in msg2 to be init but got %v", msg["block"])
+	}
+}
+
+// TestWarnOnce will test that WarnOnce only prints the first
+// warning for each key, also from several goroutines.
+func TestWarnOnce(t *testing.T) {
+	client := Create(nil, Input{"llogger-wm": "warning"})
+
+	// The keys are shared by the process, so forget them in
+	// case the test is run more than once.
+	warnedKeys.Delete("TestWarnOnce-1")
+	warnedKeys.Delete("TestWarnOnce-2")
+
+	strs := capture(t, func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				client.WarnOnce("TestWarnOnce-1", "Testmessage1", Input{"feature": "legacy"})
+			}()
+		}
+		wg.Wait()
+		client.WarnOnce("TestWarnOnce-1", "Testmessage2", nil)
+		client.WarnOnce("TestWarnOnce-2", "Testmessage3", nil)
+	})
+
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 lines from stdout, one per key, but got %q", strs)
+	}
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	switch {
+	case msg1["loglevel"] != "warning" || msg1["message"] != "Testmessage1" || msg1["feature"] != "legacy":
+		t.Fatalf("Expected warning with message Testmessage1 in msg1 but got %s", strs[0])
+
+	case msg2["message"] != "Testmessage3":
+		t.Fatalf("Expected warning with message Testmessage3 in msg2 but got %s", strs[1])
+	}
+}
+
+// TestLogError will test printing errors with LogError.
+func TestLogError(t *testing.T) {
+	client := Create(nil, Input{"llogger-cm": "critical"})
+	err := errors.New("flux capacitor")
+	wrapped := fmt.Errorf("couldn't time travel: %v", err)
+
+	strs := capture(t, func() {
+		client.LogError(err, Input{"requestId": "1337"})
+		client.LogError(wrapped, Input{"message": "Testmessage2"})
+		client.LogError(nil, Input{"message": "Testmessage3"})
+		client.LogError((*nilError)(nil), Input{"message": "Testmessage4"})
+	})
+
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 lines from stdout since nil and typed nil errors are not printed but got %d", len(strs))
+	}
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	switch {
+	case msg1["loglevel"] != "critical" || msg1["message"] != "flux capacitor" || msg1["requestId"] != "1337":
+		t.Fatalf("Expected critical error with message flux capacitor in msg1 but got %s", strs[0])
+
+	case msg2["loglevel"] != "critical" || msg2["message"] != "Testmessage2":
+		t.Fatalf("Expected critical error with message Testmessage2 in msg2 but got %s", strs[1])
+
+	case msg2["error"] != "couldn't time travel: flux capacitor":
+		t.Fatalf("Expected wrapped error in error field in msg2 but got %v", msg2["error"])
+	}
+}
+
+// TestWrapError will test printing and wrapping errors with WrapError.
+func TestWrapError(t *testing.T) {
+	client := Create(nil, Input{"llogger-cm": "critical"})
+	err := errors.New("flux capacitor")
+
+	var err1, err2, err3, err4 error
+	strs := capture(t, func() {
+		err1 = client.WrapError(err, "couldn't time travel")
+		err2 = client.WrapError(err, "")
+		err3 = client.WrapError(nil, "Testmessage3")
+		err4 = client.WrapError((*nilError)(nil), "Testmessage4")
+	})
+
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 lines from stdout since nil and typed nil errors are not printed but got %d", len(strs))
+	}
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	switch {
+	case msg1["loglevel"] != "critical" || msg1["message"] != "couldn't time travel" || msg1["error"] != "flux capacitor":
+		t.Fatalf("Expected critical error with message and error field in msg1 but got %s", strs[0])
+
+	case err1 == nil || err1.Error() != "couldn't time travel: flux capacitor":
+		t.Fatalf("Expected err1 to have the message and err but got %v", err1)
+
+	case err1.(interface{ Unwrap() error }).Unwrap() != err:
+		t.Fatalf("Expected err1 to wrap err with the message but got %v", err1)
+
+	case msg2["message"] != "flux capacitor" || err2 != err:
+		t.Fatalf("Expected err2 to be err as is with its message in msg2 but got %v and %s", err2, strs[1])
+
+	case err3 != nil:
+		t.Fatalf("Expected nil error to return nil but got %v", err3)
+
+	case err4 == nil || err4.(*nilError) != nil:
+		t.Fatalf("Expected typed nil error to be returned as is but got %#v", err4)
+	}
+}
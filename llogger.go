@@ -2,11 +2,16 @@
 package llogger
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"runtime"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // var (
@@ -19,6 +24,7 @@ import (
 // lambda deadline is reached.
 type Client struct {
 	data     Input
+	initial  Input // Copy of data from Create, restored by Reset
 	context  context.Context
 	start    time.Time
 	deadline time.Time
@@ -43,6 +49,23 @@ type Client struct {
 	pre string // Prefix
 	suf string // Suffix
 
+	// The separators between the prefix and the message and
+	// between the message and the suffix. Only added when the
+	// prefix or suffix isn't empty. Set with llogger-presep and
+	// llogger-sufsep in Input.
+	preSep string
+	sufSep string
+
+	// The max length in bytes of the prefix and suffix, 0 means
+	// no limit. Set with llogger-maxprefix in Input.
+	maxPrefix int
+
+	// The newline added after each message. Defaults to
+	// \n and can be changed with llogger-newline or removed
+	// with llogger-nonewline in Input for transports that
+	// frame messages themselves.
+	newline string
+
 	// The warning and critical log levels. Can be
 	// set by setting the llogger-wm and llogger-cm
 	// keys in inp when creating the client.
@@ -57,6 +80,214 @@ type Client struct {
 	// in Input.
 	tf string // Time format to use
 
+	// The unit epoch timestamps are truncated to, for example a
+	// second to bucket by whole seconds. 0 means no truncation.
+	// Set with llogger-epochtrunc in Input.
+	epochTrunc time.Duration
+
+	// The format used for the output. Defaults to
+	// json and can be set to msgpack, gelf, flat, console
+	// or telemetry with llogger-format in Input.
+	format string // Output format
+	host   string // Hostname used by the gelf format
+
+	// The key of the envelope messages are wrapped in and the
+	// static fields of the envelope. Set with llogger-envelope
+	// and llogger-envfields in Input.
+	envelope  string
+	envFields map[string]json.RawMessage
+
+	// If true only the last directory and file name is used
+	// for the file of the resource. Set with llogger-shortpath
+	// in Input.
+	shortPath bool
+
+	// If true the resource is printed as a flat string in the
+	// caller field instead of an object. Set with llogger-caller
+	// in Input.
+	flatCaller bool
+
+	// If true the function of the resource is split into
+	// package and function. Set with llogger-splitfunc
+	// in Input.
+	splitFunc bool
+
+	// The field names for the optional fields. An empty
+	// field name means that the field is disabled.
+	efn  string // epoch fieldname
+	gfn  string // goroutine fieldname
+	sfn  string // sampled fieldname
+	psfn string // process start fieldname
+	upfn string // uptime fieldname
+	rgfn string // region fieldname
+	szfn string // size fieldname
+	ltfn string // level token fieldname
+	tsfn string // timestamps fieldname
+	pkfn string // package fieldname
+	xifn string // X-Ray trace ID fieldname
+	xsfn string // X-Ray trace sampled fieldname
+	afn  string // invoked function ARN fieldname
+	stfn string // stage fieldname
+	evfn string // event ID fieldname
+	ngfn string // number of goroutines fieldname
+	dpfn string // deployment fieldname
+	gvfn string // Go version fieldname
+	scfn string // schema version fieldname
+
+	// The format of the level token, with %s for the upper case
+	// log level. If ltPrefix is true the token is added as prefix
+	// instead of as a field.
+	ltf      string
+	ltPrefix bool
+
+	region string // AWS region read from the environment at Create
+	arn    string // Invoked function ARN from the Lambda context
+
+	// The deployment identifier resolved from the sources set
+	// with llogger-deployment at Create.
+	deployment string
+
+	// The schema version set with llogger-schema, a string
+	// or a number.
+	schema interface{}
+
+	// The stage read from the environment variable set with
+	// llogger-stageenv at Create.
+	stageEnv string
+
+	// Default fields added to messages with a specific log
+	// level. Set with llogger-levelfields in Input.
+	levelFields map[string]Input
+
+	// Formatters for values of specific types. Set with
+	// llogger-formatters in Input.
+	formatters map[reflect.Type]Formatter
+
+	// Fields whose string values are parsed as numbers or bools.
+	// Set with llogger-coerce in Input.
+	coerce map[string]bool
+
+	// Keys printed first, in this order, before the other keys
+	// sorted. Set with llogger-keyorder in Input.
+	keyOrder []string
+
+	// Top level fields of the event added to the summary
+	// printed by Start. Set with llogger-eventfields in Input.
+	eventFields []string
+
+	// Keys of the event whose values are redacted in the summary
+	// printed by Start. Set with llogger-evredact in Input.
+	eventRedact map[string]bool
+
+	// If true integers above 2^53 are encoded as strings.
+	// Set with llogger-safeints in Input.
+	safeInts bool
+
+	// The log level pinned with Level. Used for all messages
+	// that don't set the log level themselves.
+	level string
+
+	// How built in fields set by the user are handled, "user"
+	// to keep the value of the user or "warn" to add the
+	// overwrittenFields field. Set with llogger-builtins in Input.
+	builtins string
+
+	// How the duration and timeLeft fields are rendered, "" for
+	// seconds or "string" for Go duration strings. Set with
+	// llogger-durations in Input.
+	durations string
+
+	// How NaN and infinite floats are handled, "" to replace
+	// them with strings, "null" to replace them with null or
+	// "error" to fail. Set with llogger-nonfinite in Input.
+	nonFinite string
+
+	// How Print handles an empty Input, "warn" to print a warning
+	// or "minimal" to print {}. Set with llogger-empty in Input.
+	empty string
+
+	// If true the fields printed by HTTPRequest are nested in
+	// an http field instead of flat http.* fields. Set with
+	// llogger-http in Input.
+	httpNested bool
+
+	// The log level of ValidationErrors, "" means the warning
+	// log level. Set with llogger-vlevel in Input.
+	validationLevel string
+
+	// If true the sorted names of the fields in Input that
+	// shadow fields in data are added in the shadowedFields
+	// field. Set with llogger-shadowed in Input.
+	shadowed bool
+
+	// The max number of fields in a message, 0 means no
+	// limit. Set with llogger-maxfields in Input.
+	maxFields int
+
+	// The max size of a message in bytes, 0 means no limit.
+	// Set with llogger-maxsize in Input.
+	maxSize int
+
+	// The max depth of objects and arrays in field values, 0
+	// means no limit. Set with llogger-maxdepth in Input.
+	maxDepth int
+
+	// The max size in bytes of a line before it's split into
+	// linked parts, 0 means no splitting. Set with llogger-split
+	// in Input.
+	splitSize int
+
+	// Custom log levels in order of increasing severity. Set
+	// with llogger-levels in Input.
+	customLevels []string
+
+	// Index in levels of the minimum log level, 0 means that
+	// all messages are printed. Set with llogger-minlevel in Input.
+	minLevel int
+
+	// Messages with a log level at or below this are printed as
+	// indented JSON. Set with llogger-pretty in Input.
+	pretty string
+
+	// The sampler deciding which messages to keep. Configured
+	// with llogger-sample and llogger-seed in Input.
+	sampler *sampler
+
+	// Where messages are written. Defaults to stdout and can be
+	// set with llogger-writer and llogger-buffered in Input.
+	sink *sink
+
+	// The deadline monitor flushes buffered messages flushBefore
+	// the deadline. Set with llogger-flushbefore in Input.
+	monitor     *monitorState
+	flushBefore time.Duration
+
+	// If true a warning is printed when 25% of the execution time
+	// is left and a critical message when 10% is left. Set with
+	// llogger-deadlinelog in Input.
+	deadlineLogs bool
+
+	// If true a context without a deadline is kept, for its
+	// cancellation and values, and only duration and timeLeft
+	// are left out. Set with llogger-keepcontext in Input.
+	keepContext bool
+
+	// Called when a message can't be encoded. Set
+	// with llogger-onerror in Input.
+	onError ErrorHandler
+
+	// Counts the printed messages per log level for the summary
+	// printed by Close. Set with llogger-summary in Input.
+	counter *counter
+
+	// The memory monitor warns when the heap usage crosses a
+	// percentage of the memory limit. Set with llogger-memwarn.
+	memMonitor *monitor
+
+	// The shutdown monitor prints a message and flushes when the
+	// context set with llogger-shutdown is done.
+	shutdownMonitor *monitor
+
 	// Warning  chan<- time.Duration
 	// Critical chan<- time.Duration
 }
@@ -68,7 +299,23 @@ type Input map[string]interface{}
 
 type output map[string]interface{}
 
-type resource struct {
+// call contains the per call settings used by print.
+type call struct {
+	skip  int             // Stack frames to ascend to find the resource, 0 being print
+	pc    uintptr         // Program counter to use for the resource instead of skip
+	ctx   context.Context // Context whose deadline is used over the deadline of the client
+	omit  []string        // Fields normally set by the client to leave out
+	time  time.Time       // Time of the message, set by print if zero
+	wrap  bool            // Use pre and suf instead of the prefix and suffix of the client
+	force bool            // Print even if dropped by sampling or the minimum log level
+	pre   string          // Prefix used if wrap is true
+	suf   string          // Suffix used if wrap is true
+}
+
+// Resource is the function, file and row of the code that printed
+// a message. It's printed in the resource field.
+type Resource struct {
+	Package  string `json:"package,omitempty"`
 	Function string `json:"function"`
 	File     string `json:"file"`
 	Row      int    `json:"row"`
@@ -78,51 +325,448 @@ type resource struct {
 // All fields left empty will be omitted in the JSON output.
 // If ctx was set to nil in *Client Duration and TimeLeft will
 // not be set.
+//
+// How an empty inp is handled is set with llogger-empty, see
+// printEmpty.
 func (l *Client) Print(inp Input) {
+	l = l.orDefault()
+	if len(inp) == 0 && l.empty != "" {
+		l.printEmpty()
+		return
+	}
+	l.print(call{skip: 2}, inp)
+}
+
+// printEmpty will handle a Print with an empty Input with the policy
+// set by llogger-empty. With warn a message with the warning log level
+// about the empty log call is printed instead, with the resource of the
+// caller of Print. With minimal only {} is printed, with the prefix,
+// suffix and line terminator.
+func (l *Client) printEmpty() {
+	switch l.empty {
+	case "warn":
+		l.print(call{skip: 3}, Input{l.llfn: l.wm, l.mfn: "Empty log message"})
+
+	case "minimal":
+		pre, suf := l.separate(l.pre, l.suf)
+		l.write(l.frame(pre, []byte("{}"), suf))
+	}
+}
+
+// Printf takes format and args and prints the formatted string
+// as message. The log level is only set if the client has a
+// pinned log level, see Level.
+func (l *Client) Printf(format string, args ...interface{}) {
+	l = l.orDefault()
+	l.print(call{skip: 2}, Input{l.mfn: fmt.Sprintf(format, args...)})
+}
+
+// Log takes log level level, message msg and inp and prints it as a
+// JSON to stdout with level and msg set in the log level and message
+// fields. This is useful when the log level and message field names
+// are not known, for example when used from adapters.
+func (l *Client) Log(level string, msg string, inp Input) {
+	l = l.orDefault()
+	l.print(call{skip: 2}, l.leveled(level, msg, inp))
+}
+
+// PrintPrefixed takes prefix, suffix and inp and prints inp as a JSON
+// to stdout with prefix and suffix instead of the prefix and suffix of
+// the client. The client is not changed so following calls to Print
+// will use its prefix and suffix as usual.
+func (l *Client) PrintPrefixed(prefix string, suffix string, inp Input) {
+	l = l.orDefault()
+	l.print(call{skip: 2, wrap: true, pre: prefix, suf: suffix}, inp)
+}
+
+// SetPrefix sets the prefix of l to prefix, truncated to the max
+// length set with llogger-maxprefix. It's not safe to call while
+// other goroutines print with l.
+func (l *Client) SetPrefix(prefix string) {
+	l = l.orDefault()
+	l.pre = l.truncateAffix(prefix)
+}
+
+// SetSuffix sets the suffix of l to suffix, truncated to the max
+// length set with llogger-maxprefix. It's not safe to call while
+// other goroutines print with l.
+func (l *Client) SetSuffix(suffix string) {
+	l = l.orDefault()
+	l.suf = l.truncateAffix(suffix)
+}
+
+// separate will return pre and suf with the separators set with
+// llogger-presep and llogger-sufsep added, if they aren't empty.
+// Returns string and string.
+func (l *Client) separate(pre string, suf string) (string, string) {
+	if pre != "" {
+		pre += l.preSep
+	}
+	if suf != "" {
+		suf = l.sufSep + suf
+	}
+
+	return pre, suf
+}
+
+// truncateAffix will return the prefix or suffix str truncated to
+// l.maxPrefix bytes. It's truncated at the start of a UTF-8 character
+// so it's still valid UTF-8.
+// Returns string.
+func (l *Client) truncateAffix(str string) string {
+	if l.maxPrefix <= 0 || len(str) <= l.maxPrefix {
+		return str
+	}
+
+	n := l.maxPrefix
+	for n > 0 && !utf8.RuneStart(str[n]) {
+		n--
+	}
+
+	return str[:n]
+}
+
+// PrintContext takes ctx and inp and prints inp as a JSON to stdout.
+// If ctx has a deadline Duration and TimeLeft will be based on it
+// instead of the context of *Client. This is useful when a client is
+// reused for operations that have their own timeouts.
+// If ctx is nil or has no deadline it works just like Print.
+func (l *Client) PrintContext(ctx context.Context, inp Input) {
+	l = l.orDefault()
+	l.print(call{skip: 2, ctx: ctx}, inp)
+}
+
+// PrintAt takes t and inp and prints inp as a JSON to stdout with t as
+// the time instead of the current time. This is useful when replaying or
+// backfilling events. The optional fields based on the time, such as
+// epoch, also use t. Duration and TimeLeft are still based on the
+// current time since they describe the execution, not the event.
+func (l *Client) PrintAt(t time.Time, inp Input) {
+	l = l.orDefault()
+	l.print(call{skip: 2, time: t}, inp)
+}
+
+// PrintFrom takes pc and inp and prints inp as a JSON to stdout with
+// the function, file and row of the program counter pc as resource.
+// This lets frameworks that print from their own dispatch loop report
+// the call site of the application, with a pc from runtime.Caller or
+// runtime.Callers. If pc is 0 the caller of PrintFrom is used.
+func (l *Client) PrintFrom(pc uintptr, inp Input) {
+	l = l.orDefault()
+	l.print(call{skip: 2, pc: pc}, inp)
+}
+
+// Build takes inp and returns the output Print would print, with the
+// data of the client and all built in fields such as time, resource and
+// duration, without marshaling or writing it. This is useful in tests and
+// hooks to inspect the structured form. Messages are never dropped by
+// sampling or the minimum log level and llogger-maxsize isn't applied
+// since it depends on the marshaled size.
+// Returns Input.
+func (l *Client) Build(inp Input) Input {
+	l = l.orDefault()
+	out, _, _ := l.build(call{skip: 2, force: true}, inp)
+
+	return Input(out)
+}
+
+// build takes inp and returns the output to print using the per call
+// settings in c, together with the values of the built in fields set by
+// the user. ok is false if the message is dropped by the minimum log
+// level or sampling. c.skip is counted from build.
+// Returns output, map[string]interface{} and bool.
+func (l *Client) build(c call, inp Input) (output, map[string]interface{}, bool) {
+	if c.time.IsZero() {
+		c.time = time.Now()
+	}
+
+	// Save the values of built in fields set by the user
+	// before they are overwritten.
+	user := l.userFields(inp)
+
 	// Creates a basic output that merges data form l and inp.
-	out := l.createOutput(inp)
+	out := l.createOutput(c, inp)
+
+	// Drop the message if its log level is below the minimum
+	// log level or if it's not kept by sampling.
+	if !c.force && !l.enabled(out[l.llfn]) {
+		return nil, nil, false
+	}
+	sampled := c.force || l.sample(out)
+	if !sampled {
+		return nil, nil, false
+	}
+	if l.sfn != "" {
+		out[l.sfn] = sampled
+	}
 
 	// Fetch and set the calling function filename and line.
-	// This call will never fail since skip is 1 and there
+	// Use the program counter in c if set, otherwise ascend
+	// c.skip frames. This call will never fail since there
 	// is always a caller. So skip ok variable.
-	fptr, file, row, _ := runtime.Caller(1)
-	funcName := runtime.FuncForPC(fptr).Name()
-	out[l.rfn] = resource{
-		Function: funcName,
-		File:     file,
-		Row:      row,
+	fptr, file, row, _ := runtime.Caller(c.skip)
+	funcName := funcNameForPC(fptr)
+	if c.pc != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{c.pc}).Next()
+		funcName, file, row = frame.Function, frame.File, frame.Line
 	}
-
-	raw, err := json.Marshal(out)
+	res := l.resource(funcName, file, row)
 	switch {
+	case l.flatCaller:
+		out[l.rfn] = res.String()
+
+	default:
+		out[l.rfn] = res
+	}
+	if l.pkfn != "" {
+		out[l.pkfn], _ = splitFuncName(funcName)
+	}
+
+	// Restore or warn about the built in fields set by the user.
+	l.applyUserFields(out, user)
+
+	// Remove the fields that should be left out.
+	for _, k := range c.omit {
+		delete(out, k)
+	}
+
+	// Drop fields above the max field count.
+	l.limitFields(out)
+
+	return out, user, true
+}
+
+// print takes inp and prints it as a JSON to stdout using the
+// per call settings in c.
+func (l *Client) print(c call, inp Input) {
+	if c.time.IsZero() {
+		c.time = time.Now()
+	}
+
+	// Build the output, c.skip is counted from print.
+	c.skip++
+	out, user, ok := l.build(c, inp)
+	if !ok {
+		return
+	}
+	l.count(out[l.llfn])
+
+	// Use the prefix and suffix from c if set.
+	pre, suf := l.pre, l.suf
+	if c.wrap {
+		pre, suf = c.pre, c.suf
+	}
+	pre, suf = l.separate(pre, suf)
+
+	// Add the level token before the prefix if enabled.
+	if token, ok := l.levelToken(out[l.llfn]); ok && l.ltPrefix {
+		pre = token + " " + pre
+	}
+
 	// If JSON Marshal fails print a error message about failing JSON Marshal.
 	// Don't print the original error message since it probably contains not so
 	// good data that possibly could break other things.
-	case err != nil:
-		l.Print(Input{l.llfn: l.cm, l.mfn: "Couldn't JSON marshal the error message"})
+	raw, err := l.marshal(out)
+	if err == nil && l.maxSize > 0 && len(raw) > l.maxSize {
+		raw, err = l.limitSize(out, raw)
+	}
+	_, userSize := user[l.szfn]
+	if err == nil && l.szfn != "" && !(userSize && l.builtins == "user") {
+		// The size is the byte length of the JSON without the size
+		// field, since adding it changes the size.
+		out[l.szfn] = len(raw)
+		raw, err = l.marshal(out)
+	}
+	if err != nil {
+		l.encodeFailed(err, "Couldn't JSON marshal the error message", pre, suf)
+		return
+	}
 
-	default:
-		fmt.Printf("%s%s%s\n", l.pre, raw, l.suf)
+	line, ok := l.render(c, out[l.llfn], raw, pre, suf)
+	if !ok {
+		return
+	}
+
+	// Split lines that are too large into linked parts if enabled.
+	// The parts get the same prefix, suffix and envelope, so their
+	// JSON can use what's left of the max size after those.
+	if l.splitSize > 0 && len(line) > l.splitSize && l.format == "json" {
+		if parts, ok := l.splitParts(out, raw, l.splitSize-(len(line)-len(raw))); ok {
+			for _, part := range parts {
+				if line, ok := l.render(c, out[l.llfn], part, pre, suf); ok {
+					l.write(line)
+				}
+			}
+			return
+		}
 	}
+
+	l.write(line)
+}
+
+// render will return the line to write for the JSON message raw with
+// log level level. The JSON is wrapped in the envelope, encoded with
+// the output format, indented if enabled for level and framed with pre
+// and suf. If encoding fails the failure is handled and ok is false.
+// Returns []byte and bool.
+func (l *Client) render(c call, level interface{}, raw []byte, pre string, suf string) ([]byte, bool) {
+	// Wrap the JSON in the envelope if enabled.
+	if l.envelope != "" {
+		raw = l.wrapEnvelope(raw)
+	}
+
+	// Encode the JSON with the output format.
+	body, err := l.encode(c, raw)
+	if err != nil {
+		l.encodeFailed(err, "Couldn't encode the message with format "+l.format, pre, suf)
+		return nil, false
+	}
+
+	// Indent the JSON of messages at or below the pretty log level.
+	if l.indented(level) {
+		var buf bytes.Buffer
+		if json.Indent(&buf, body, "", "  ") == nil {
+			body = buf.Bytes()
+		}
+	}
+
+	return l.frame(pre, body, suf), true
+}
+
+// frame will return body with pre, suf and the line terminator added.
+// MessagePack is binary and self delimiting so no line terminator is
+// added. In array mode body is returned as is since the sink adds the
+// delimiters of the array.
+// Returns []byte.
+func (l *Client) frame(pre string, body []byte, suf string) []byte {
+	if l.sink.array {
+		return body
+	}
+
+	line := make([]byte, 0, len(pre)+len(body)+len(suf)+len(l.newline))
+	line = append(append(append(line, pre...), body...), suf...)
+	if l.format != "msgpack" {
+		line = append(line, l.newline...)
+	}
+
+	return line
+}
+
+// ErrorHandler is called with the error when a message can't be
+// JSON marshaled or encoded with the output format. It can be set with
+// llogger-onerror in Input when creating the client.
+type ErrorHandler func(err error)
+
+// encodeFailed will record err as the last error of l and call the
+// error handler of l with it. If no error handler is set a line with
+// the critical log level and msg as message is written. The line is
+// built by hand so that it can't fail, which means no other fields are
+// included and nothing is printed recursively.
+func (l *Client) encodeFailed(err error, msg string, pre string, suf string) {
+	l.sink.mu.Lock()
+	l.sink.err = err
+	l.sink.mu.Unlock()
+
+	if l.onError != nil {
+		l.onError(err)
+		return
+	}
+
+	body := `{"` + jsonEscape(l.llfn) + `":"` + jsonEscape(l.cm) + `","` +
+		jsonEscape(l.mfn) + `":"` + jsonEscape(msg) + `"}`
+	l.write(l.frame(pre, []byte(body), suf))
+}
+
+// LastError returns the last error from a message that couldn't be
+// JSON marshaled or encoded with the output format, or nil.
+// Returns error.
+func (l *Client) LastError() error {
+	l = l.orDefault()
+	l.sink.mu.Lock()
+	defer l.sink.mu.Unlock()
+
+	return l.sink.err
+}
+
+// jsonEscape will escape str so it can be used inside
+// a JSON string without calling json.Marshal.
+// Returns string.
+func jsonEscape(str string) string {
+	var b strings.Builder
+	for _, r := range str {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+
+		case r < 0x20:
+			fmt.Fprintf(&b, "\\u%04x", r)
+
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// marshal will JSON marshal out. If marshaling panics, for example
+// because of a value with a MarshalJSON method that panics, each value
+// is marshaled on its own and all values that panic are replaced with
+// a placeholder. The field names and panics are added to the
+// marshalErrors field so the rest of the message can still be printed.
+// Returns []byte and error.
+func (l *Client) marshal(out output) ([]byte, error) {
+	raw, ok, err := safeMarshal(out, l.keyOrder)
+	if ok {
+		return raw, err
+	}
+
+	errs := map[string]string{}
+	for k, v := range out {
+		if _, ok, err := safeMarshal(v, nil); !ok {
+			out[k] = "<marshal panic>"
+			errs[k] = err.Error()
+		}
+	}
+	out["marshalErrors"] = errs
+
+	raw, _, err = safeMarshal(out, l.keyOrder)
+	return raw, err
+}
+
+// safeMarshal will JSON marshal v and recover from any panic
+// during marshaling. If a panic was recovered ok is false and
+// err contains the recovered value.
+// Returns []byte, bool and error.
+func safeMarshal(v interface{}, first []string) (raw []byte, ok bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			raw, ok, err = nil, false, fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	if out, ok := v.(output); ok {
+		raw, err = marshalOutput(out, first)
+	} else {
+		raw, err = json.Marshal(v)
+	}
+	return raw, true, err
 }
 
 // createOutput will return output that contains the
 // merged data from l.data and inp. If l.context is
 // set duration and time_left will also be set based
-// on data from the lambda context.
+// on data from the lambda context. If c.ctx has a
+// deadline it's used instead of l.deadline.
 // Returns output.
-func (l *Client) createOutput(inp Input) output {
+func (l *Client) createOutput(c call, inp Input) output {
 	out := output{}
 
-	switch l.tf {
-	case "Unix":
-		out[l.tfn] = time.Now().Unix()
-
-	case "UnixNano":
-		out[l.tfn] = time.Now().UnixNano()
-
-	default:
-		out[l.tfn] = time.Now().Format(l.tf)
+	// Set the pinned log level, if any, before merging
+	// so it can be overridden.
+	if l.level != "" {
+		out[l.llfn] = l.level
 	}
 
 	// Merge Input from l and Input.
@@ -132,35 +776,207 @@ func (l *Client) createOutput(inp Input) output {
 	for k, v := range inp {
 		out[k] = v
 	}
+	l.addShadowedFields(inp, out)
+
+	// Set the time and optional fields after merging so they
+	// can't be overridden, see llogger-builtins.
+	out[l.tfn] = l.formatTime(c.time)
+	l.addOptionalFields(c, out)
 
-	// Set duration and time_left if context is set.
-	if l.context != nil {
-		out[l.dfn] = time.Now().Sub(l.start).Seconds()
-		out[l.tlfn] = l.deadline.Sub(time.Now()).Seconds()
+	// Merge the default fields for the log level of the message.
+	// Fields in inp take precedence.
+	if level, ok := out[l.llfn].(string); ok {
+		for k, v := range l.levelFields[level] {
+			if _, ok := inp[k]; !ok {
+				out[k] = v
+			}
+		}
+	}
+
+	// Format values with a formatter registered for their type.
+	l.formatValues(out)
+
+	// Print errors, and slices of errors, as their messages.
+	errorValues(out)
+
+	// Parse the string values of the fields to coerce.
+	l.coerceValues(out)
+
+	// Replace NaN and infinite floats that can't be marshaled.
+	l.replaceNonFinite(out)
+
+	// Truncate values nested deeper than the max depth, after the
+	// NaN and infinite floats are replaced so they can be marshaled.
+	l.limitDepth(out)
+
+	// Encode large integers as strings if enabled.
+	if l.safeInts {
+		for k, v := range out {
+			out[k] = safeInt(v)
+		}
+	}
+
+	// Use the deadline from c.ctx if it has one.
+	deadline, ok := l.deadline, l.context != nil && !l.deadline.IsZero()
+	if c.ctx != nil {
+		if d, has := c.ctx.Deadline(); has {
+			deadline, ok = d, true
+		}
+	}
+
+	// Set duration and time_left if there is a deadline.
+	// The durations are rendered as Go duration strings, rounded
+	// to milliseconds, if llogger-durations is string.
+	if ok {
+		duration, timeLeft := time.Now().Sub(l.start), deadline.Sub(time.Now())
+		switch l.durations {
+		case "string":
+			out[l.dfn] = duration.Round(time.Millisecond).String()
+			out[l.tlfn] = timeLeft.Round(time.Millisecond).String()
+
+		default:
+			out[l.dfn] = duration.Seconds()
+			out[l.tlfn] = timeLeft.Seconds()
+		}
 	}
 
 	return out
 }
 
+// resource will return the Resource for the function funcName, file and
+// row, with the file shortened and the function split if enabled.
+// Returns Resource.
+func (l *Client) resource(funcName string, file string, row int) Resource {
+	if l.shortPath {
+		file = shortPath(file)
+	}
+	res := Resource{
+		Function: funcName,
+		File:     file,
+		Row:      row,
+	}
+	if l.splitFunc {
+		res.Package, res.Function = splitFuncName(funcName)
+	}
+
+	return res
+}
+
+// String returns r as "function (file:row)", with the package
+// prefixed to the function if it's split.
+// Returns string.
+func (r Resource) String() string {
+	function := r.Function
+	if r.Package != "" {
+		function = r.Package + "." + function
+	}
+
+	return formatResource(function, r.File, r.Row)
+}
+
+// CallerAt returns the Resource of the caller skip frames above the
+// caller of CallerAt, so 0 is the caller of CallerAt and 1 is its
+// caller. This is useful to add a specific call site as a field. If
+// there is no such frame the function is unknown and file and row
+// are empty.
+// Returns Resource.
+func (l *Client) CallerAt(skip int) Resource {
+	l = l.orDefault()
+	pc, file, row, ok := runtime.Caller(skip + 1)
+	if !ok || skip < 0 {
+		return Resource{Function: "unknown"}
+	}
+
+	return l.resource(funcNameForPC(pc), file, row)
+}
+
+// funcNameForPC will return the name of the function containing
+// the program counter pc. runtime.FuncForPC can return nil for
+// program counters it can't resolve, so "unknown" is returned
+// instead of panicking in that case.
+// Returns string.
+func funcNameForPC(pc uintptr) string {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown"
+	}
+
+	return fn.Name()
+}
+
+// splitFuncName will split the full function name name into the
+// package import path and the function name. The package ends at the
+// first . after the last /, so "github.com/a/b.(*T).Method" is split
+// into "github.com/a/b" and "(*T).Method".
+// Returns string and string.
+func splitFuncName(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+
+	return name[:slash+1+dot], name[slash+2+dot:]
+}
+
+// formatTime will return t formatted with the time
+// format of l.
+// Returns interface{}.
+func (l *Client) formatTime(t time.Time) interface{} {
+	switch l.tf {
+	case "Unix":
+		return l.epoch(t) / int64(time.Second)
+
+	case "UnixMilli":
+		return l.epoch(t) / int64(time.Millisecond)
+
+	case "UnixNano":
+		return l.epoch(t)
+
+	default:
+		return t.Format(l.tf)
+	}
+}
+
 // Create takes context ctx and Input inp and creates a llogger client. The llogger
 // client can then be used to print JSON messages to CloudWatch logs.
 // ctx should be a valid context created by AWS Lambda. If set to nil all additional
 // functionality that requires context will be disabled such as getting lambda duration,
-// time left. The channels for time left warnings will also never trigger.
+// time left. The messages for time left will also never be printed.
 // All data specified in inp will be added to each message sent by the client.
 // You can also specify the "log level" and "message" field name by adding the special
 // variables llfn for "log level field name" anf mfn "message field name" to inp.
-// If context as set and as a valid AWS Lambda context there will be events on the
-// l.Warning and l.Critical channels when the lambda detects that only 25% and 10%
-// respectively of runtime is left before it will self terminate.
+// If context as set and as a valid AWS Lambda context and llogger-deadlinelog is true
+// a warning and a critical message is printed when the lambda detects that only 25%
+// and 10% respectively of runtime is left before it will self terminate.
+// The llogger-* keys with string, bool or numeric values can also be set with
+// LLOGGER_* environment variables, keys set in inp always take precedence over
+// the environment. Keys that take Go values, llogger-formatters,
+// llogger-levelfields, llogger-writer, llogger-onerror and llogger-shutdown,
+// can only be set in inp.
 // Returns *Client.
 func Create(ctx context.Context, inp Input) *Client {
+	// The context is set last by UpdateContext, so that nothing is
+	// printed with it before the configuration is finished.
 	l := &Client{
 		data:    inp,
 		start:   time.Now().UTC(),
-		context: ctx,
+		monitor: &monitorState{},
+	}
+
+	// Make sure there is somewhere to store configuration
+	// read from the environment.
+	if l.data == nil {
+		l.data = Input{}
 	}
 
+	// Read configuration from LLOGGER_* environment variables
+	// for all keys not already set in inp.
+	l.setEnvConfig()
+
+	// Save the config keys if they should be kept in the output.
+	config := l.keepConfig()
+
 	// Set the loglevel and message field names.
 	l.setFieldNames()
 
@@ -170,18 +986,221 @@ func Create(ctx context.Context, inp Input) *Client {
 	// Set the format to use for time.
 	l.setTimeFormat()
 
+	// Set the format to use for the output.
+	l.setFormat()
+
+	// Set the envelope to wrap messages in.
+	l.setEnvelope()
+
+	// Enable the optional fields.
+	l.setOptionalFields()
+
+	// Set the sample rate.
+	l.setSampling()
+
+	// Set the log levels and the minimum log level.
+	levelsErr := l.setLevels()
+
+	// Set where to write messages.
+	l.setWriter()
+
+	// Set the max size of a line before it's split.
+	l.setSplit()
+
+	// Count the messages per log level if the summary is enabled.
+	l.setSummary()
+
+	// Read the monitor configuration. The monitors are started last.
+	startMemory := l.setMemoryMonitor()
+	startShutdown := l.setShutdownMonitor()
+
+	// Add the saved config keys back to data so they're included
+	// in all messages.
+	for k, v := range config {
+		l.data[k] = v
+	}
+
+	// Save a copy of data so it can be restored by Reset.
+	l.initial = Input{}
+	for k, v := range l.data {
+		l.initial[k] = v
+	}
+
+	// Warn if several fields are configured with the same name
+	// since one of them would silently overwrite the other.
+	if collisions := l.fieldNameCollisions(); len(collisions) > 0 {
+		l.Print(Input{l.llfn: l.wm, l.mfn: "Field names collide: " + strings.Join(collisions, ", ")})
+	}
+
+	// Warn if the custom log levels were ignored.
+	if levelsErr != nil {
+		l.Print(Input{l.llfn: l.wm, l.mfn: levelsErr.Error()})
+	}
+
 	// Set the context.
 	l.UpdateContext(ctx)
 
+	// Start the memory and shutdown monitors if enabled. They're
+	// started last since they print from their own goroutines, which
+	// must not see the client before it's fully configured.
+	startMemory()
+	startShutdown()
+
 	return l
 }
 
+// defaultClient is the client used when methods are called on a
+// nil *Client. It's created on first use.
+var (
+	defaultClient     *Client
+	defaultClientOnce sync.Once
+)
+
+// orDefault will return l, or a client with the default settings that
+// writes to stdout if l is nil. This way a client that was never created
+// still prints instead of panicking.
+// Returns *Client.
+func (l *Client) orDefault() *Client {
+	if l != nil {
+		return l
+	}
+
+	defaultClientOnce.Do(func() {
+		defaultClient = Create(nil, nil)
+	})
+
+	return defaultClient
+}
+
+// WithFields takes inp and returns a copy of l that adds inp to all
+// messages on top of the data of l. Keys in inp take precedence over
+// the data of l. llogger-* keys in inp are not applied as configuration.
+// l is not changed and the copy shares its writer with l.
+// Returns *Client.
+func (l *Client) WithFields(inp Input) *Client {
+	l = l.orDefault()
+	nl := *l
+	nl.data = Input{}
+	for k, v := range l.data {
+		nl.data[k] = v
+	}
+	for k, v := range inp {
+		nl.data[k] = v
+	}
+
+	return &nl
+}
+
+// Merge takes other and returns a copy of l whose data is the union of
+// the data of l and other. Keys in both take the value from l, so a
+// library can merge a parent client into its own client and keep its
+// fields. Only the data of other is used, all configuration such as
+// field names and the writer is kept from l. Reset on the copy restores
+// the merged data. l and other are not changed.
+// Returns *Client.
+func (l *Client) Merge(other *Client) *Client {
+	l = l.orDefault()
+	nl := *l
+	nl.data = Input{}
+	if other != nil {
+		for k, v := range other.data {
+			nl.data[k] = v
+		}
+	}
+	for k, v := range l.data {
+		nl.data[k] = v
+	}
+
+	nl.initial = Input{}
+	for k, v := range nl.data {
+		nl.initial[k] = v
+	}
+
+	return &nl
+}
+
+// Reset restores the data of l to the data l had when it was created,
+// removing all fields added since, for example by WithFields. This is
+// useful for clients that are reused between requests. The field names
+// and other configuration are not changed.
+func (l *Client) Reset() {
+	if l == nil {
+		return
+	}
+
+	l.data = Input{}
+	for k, v := range l.initial {
+		l.data[k] = v
+	}
+}
+
+// Level takes log level level and returns a copy of l where all
+// messages get level as log level unless it's set in the message.
+// This is useful to avoid repeating the log level for a block of
+// messages. l is not changed and the copy shares its writer with l.
+// Returns *Client.
+func (l *Client) Level(level string) *Client {
+	l = l.orDefault()
+	nl := *l
+	nl.level = level
+
+	return &nl
+}
+
+// WithContext takes ctx and returns a copy of l that uses ctx as its
+// context. The duration is counted from when WithContext is called and
+// the time left from the deadline of ctx, so a client created before the
+// Lambda context is available can get the time based fields later. The
+// copy has its own deadline monitor, which starts with the operation set
+// on l with SetOperation. If ctx is nil the copy has no context. l is not
+// changed and the copy shares its writer with l.
+// Returns *Client.
+func (l *Client) WithContext(ctx context.Context) *Client {
+	l = l.orDefault()
+	nl := *l
+	nl.context = nil
+	nl.deadline = time.Time{}
+	nl.start = time.Now().UTC()
+	l.monitor.mu.Lock()
+	nl.monitor = &monitorState{operation: l.monitor.operation}
+	l.monitor.mu.Unlock()
+	nl.arn = ""
+	nl.UpdateContext(ctx)
+
+	return &nl
+}
+
+// NewInvocation takes ctx and returns a copy of l for a new invocation
+// in a warm container. The copy reuses the parsed configuration of l and
+// the data l had when it was created, so it's much cheaper than Create,
+// but has its own start time, context, deadline monitor without an
+// operation, summary counts and buffer, so it can be closed at the end
+// of the invocation. Fields added since l was created, for example by
+// WithFields, are not included, like after Reset. l is not changed and
+// the copy writes to the writer of l. The memory and shutdown monitors
+// of l are not stopped when the copy is closed.
+// Returns *Client.
+func (l *Client) NewInvocation(ctx context.Context) *Client {
+	l = l.orDefault()
+	nl := *l
+	nl.data = l.initial
+	nl.sink = l.sink.fork()
+	nl.memMonitor = nil
+	nl.shutdownMonitor = nil
+	nl.monitor = &monitorState{}
+	if l.counter != nil {
+		nl.counter = &counter{counts: map[string]int{}, percentiles: l.counter.percentiles}
+	}
+
+	return nl.WithContext(ctx)
+}
+
 // UpdateContext updates the context of the Client. This is useful
 // when you have a persistent llogger in your code but want to update
 // the context on each iteration.
 func (l *Client) UpdateContext(ctx context.Context) {
-	// If context is nil there is no need to set the context.
-	if ctx == nil {
+	// If context or l is nil there is no need to set the context.
+	if ctx == nil || l == nil {
 		return
 	}
 
@@ -192,8 +1211,19 @@ func (l *Client) UpdateContext(ctx context.Context) {
 	// print an error message.
 	d, ok := l.context.Deadline()
 	switch {
+	case !ok && l.keepContext:
+		// Keep the context for cancellation and its values but
+		// leave out duration and timeLeft.
+		l.deadline = time.Time{}
+		l.stopMonitor()
+		if l.afn != "" || l.stfn != "" {
+			l.arn = invokedFunctionArn(ctx)
+		}
+		return
+
 	case !ok:
 		l.context = nil
+		l.stopMonitor()
 		l.Print(Input{l.llfn: l.cm, l.mfn: "Couldn't get Deadline from context"})
 		return
 
@@ -201,6 +1231,14 @@ func (l *Client) UpdateContext(ctx context.Context) {
 		l.deadline = d.UTC()
 	}
 
+	// Get the invoked function ARN if enabled, or needed for the stage.
+	if l.afn != "" || l.stfn != "" {
+		l.arn = invokedFunctionArn(ctx)
+	}
+
+	// Start the deadline monitor for the new deadline.
+	l.startMonitor()
+
 	// Set duration, warning and critical levels.
 	// And create the channels for sending messages
 	// back to the calling function.
@@ -245,6 +1283,32 @@ func (l *Client) UpdateContext(ctx context.Context) {
 // 	l.Critical <- l.deadline.Sub(time.Now())
 // }
 
+// FieldNames are the names of the standard fields used by a client.
+type FieldNames struct {
+	Time     string
+	LogLevel string
+	Message  string
+	Duration string
+	TimeLeft string
+	Resource string
+}
+
+// FieldNames returns the names of the standard fields used by l, with
+// the names configured with llogger-tfn, llogger-llfn and so on, so
+// hooks and adapters don't have to hardcode the defaults.
+// Returns FieldNames.
+func (l *Client) FieldNames() FieldNames {
+	l = l.orDefault()
+	return FieldNames{
+		Time:     l.tfn,
+		LogLevel: l.llfn,
+		Message:  l.mfn,
+		Duration: l.dfn,
+		TimeLeft: l.tlfn,
+		Resource: l.rfn,
+	}
+}
+
 // setFieldNames will set the default key names for the log level and message
 // field. If not specified by env variables it will default to "loglevel"
 // and "message".
@@ -297,10 +1361,15 @@ func (l *Client) setFieldNames() {
 		delete(l.data, "llogger-rfn")
 	}
 
+	// Max length of the prefix and suffix in bytes.
+	if max, ok := l.configInt("llogger-maxprefix"); ok && max > 0 {
+		l.maxPrefix = int(max)
+	}
+
 	// Add prefix to output if supplied.
 	if pre, ok := l.data["llogger-prefix"]; ok {
 		if str, ok := pre.(string); ok {
-			l.pre = str
+			l.pre = l.truncateAffix(str)
 		}
 		delete(l.data, "llogger-prefix")
 	}
@@ -308,11 +1377,28 @@ func (l *Client) setFieldNames() {
 	// Add suffix to output if supplied.
 	if suf, ok := l.data["llogger-suffix"]; ok {
 		if str, ok := suf.(string); ok {
-			l.suf = str
+			l.suf = l.truncateAffix(str)
 		}
 		delete(l.data, "llogger-suffix")
 	}
 
+	// Separators between the prefix, message and suffix.
+	if sep, ok := l.configString("llogger-presep"); ok {
+		l.preSep = sep
+	}
+	if sep, ok := l.configString("llogger-sufsep"); ok {
+		l.sufSep = sep
+	}
+
+	// Set the line terminator, or remove it if requested.
+	l.newline = "\n"
+	if newline, ok := l.configString("llogger-newline"); ok && newline != "" {
+		l.newline = newline
+	}
+	if noNewline, _ := l.configBool("llogger-nonewline"); noNewline {
+		l.newline = ""
+	}
+
 	// Check that Log Level and Message is not empty. If they are empty
 	// default to field names "time", loglevel", "message", "duration",
 	// "timeLeft" and "resource".
@@ -336,6 +1422,53 @@ func (l *Client) setFieldNames() {
 	}
 }
 
+// fieldNameCollisions will return a description of each pair of
+// configured fields that use the same field name, for example
+// "loglevel and message are both named level". Disabled optional
+// fields are not checked.
+// Returns []string.
+func (l *Client) fieldNameCollisions() []string {
+	fields := []struct{ field, name string }{
+		{"time", l.tfn},
+		{"loglevel", l.llfn},
+		{"message", l.mfn},
+		{"duration", l.dfn},
+		{"timeLeft", l.tlfn},
+		{"resource", l.rfn},
+		{"epoch", l.efn},
+		{"goroutine", l.gfn},
+		{"goroutines", l.ngfn},
+		{"deployment", l.dpfn},
+		{"goVersion", l.gvfn},
+		{"schemaVersion", l.scfn},
+		{"sampled", l.sfn},
+		{"processStart", l.psfn},
+		{"uptime", l.upfn},
+		{"region", l.rgfn},
+		{"size", l.szfn},
+		{"levelToken", l.ltfn},
+		{"timestamps", l.tsfn},
+		{"package", l.pkfn},
+		{"traceId", l.xifn},
+		{"traceSampled", l.xsfn},
+		{"functionArn", l.afn},
+		{"stage", l.stfn},
+		{"eventId", l.evfn},
+	}
+
+	collisions := []string{}
+	for i := range fields {
+		for j := i + 1; j < len(fields); j++ {
+			if fields[i].name != "" && fields[i].name == fields[j].name {
+				collisions = append(collisions, fmt.Sprintf("%s and %s are both named %s",
+					fields[i].field, fields[j].field, fields[i].name))
+			}
+		}
+	}
+
+	return collisions
+}
+
 // setErrorMessages will set the default log level warning and error messages
 // If not specified by env variables it will default to "warning"
 // and "error".
@@ -383,4 +1516,104 @@ func (l *Client) setTimeFormat() {
 	if l.tf == "" {
 		l.tf = "2006-01-02 15:04:05.999999"
 	}
+
+	// Unit to truncate epoch timestamps to.
+	if trunc, ok := l.configDuration("llogger-epochtrunc"); ok && trunc > 0 {
+		l.epochTrunc = trunc
+	}
+}
+
+// epoch will return t as nanoseconds since the Unix epoch, truncated
+// to a multiple of l.epochTrunc if it's set.
+// Returns int64.
+func (l *Client) epoch(t time.Time) int64 {
+	ns := t.UnixNano()
+	if l.epochTrunc > 0 {
+		ns -= ns % int64(l.epochTrunc)
+	}
+
+	return ns
+}
+
+// keepConfig will return all llogger-* keys in l.data with a string,
+// bool or numeric value if llogger-keepconfig is set to true, or a
+// string that can be parsed as true. Other values are left out since
+// they can't be safely printed.
+// If llogger-keepconfig isn't true nil is returned.
+func (l *Client) keepConfig() Input {
+	if keep, _ := l.configBool("llogger-keepconfig"); !keep {
+		return nil
+	}
+
+	config := Input{}
+	for k, v := range l.data {
+		if !strings.HasPrefix(k, "llogger-") {
+			continue
+		}
+
+		switch v.(type) {
+		case string, bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+			config[k] = v
+		}
+	}
+
+	return config
+}
+
+// encode will return the JSON message raw encoded with the
+// output format of l using the per call settings in c.
+// Returns []byte and error.
+func (l *Client) encode(c call, raw []byte) ([]byte, error) {
+	switch l.format {
+	case "msgpack":
+		return jsonToMsgpack(raw, l.keyOrder)
+
+	case "gelf":
+		return l.toGELF(raw, c.time)
+
+	case "flat":
+		return toFlat(raw)
+
+	case "console":
+		return l.toConsole(raw)
+
+	case "telemetry":
+		return toTelemetry(raw, c.time), nil
+
+	default:
+		return raw, nil
+	}
+}
+
+// setFormat will set the format to use for the output. Will default
+// to "json" and can be set to "msgpack" for MessagePack encoding,
+// "gelf" for GELF 1.1, "flat" for JSON without nested objects,
+// "console" for lines that are easy to read in a terminal or
+// "telemetry" for AWS Lambda Telemetry API records.
+func (l *Client) setFormat() {
+	// Try and get Format from l.data as a string.
+	if format, ok := l.configString("llogger-format"); ok {
+		l.format = format
+	}
+
+	// Check that format was set. If empty set to default json.
+	// auto is resolved once the writer is known, see setWriter.
+	if l.format == "" {
+		l.format = "json"
+	}
+
+	// Try and get Short Path from l.data as a bool.
+	if shortPath, ok := l.configBool("llogger-shortpath"); ok {
+		l.shortPath = shortPath
+	}
+
+	// Try and get Split Func from l.data as a bool.
+	if splitFunc, ok := l.configBool("llogger-splitfunc"); ok {
+		l.splitFunc = splitFunc
+	}
+
+	// GELF requires the host so look it up once.
+	if l.format == "gelf" {
+		l.host = hostname()
+	}
 }
@@ -5,8 +5,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -23,7 +26,7 @@ type message1 struct {
 	Message  string   `json:"message"`
 	Duration float64  `json:"duration"`
 	TimeLeft float64  `json:"timeLeft"`
-	Resource resource `json:"resource"`
+	Resource Resource `json:"resource"`
 	Extra    string   `json:"extra"`
 }
 
@@ -36,14 +39,14 @@ type message2 struct {
 	Message  string   `json:"custom-message"`
 	Duration float64  `json:"custom-duration"`
 	TimeLeft float64  `json:"custom-timeLeft"`
-	Resource resource `json:"custom-resource"`
+	Resource Resource `json:"custom-resource"`
 }
 
 type message3 struct {
 	Time     string   `json:"time"`
 	LogLevel string   `json:"loglevel"`
 	Message  string   `json:"message"`
-	Resource resource `json:"resource"`
+	Resource Resource `json:"resource"`
 }
 
 var (
@@ -282,3 +285,1049 @@ func msg4(raw string, t *testing.T) {
 		t.Fatalf("Expected JSON Marshal to fail in msg4. But got %s", raw)
 	}
 }
+
+// capture will redirect stdout while running fn and return
+// all lines printed to stdout without the trailing blank line.
+func capture(t *testing.T, fn func()) []string {
+	return strings.Split(strings.TrimSuffix(captureRaw(t, fn), "\n"), "\n")
+}
+
+// captureRaw will redirect stdout while running fn and
+// return everything printed to stdout.
+func captureRaw(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Couldn't create new Pipe files. Error %s", err.Error())
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	raw := make(chan []byte)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		raw <- buf.Bytes()
+	}()
+
+	fn()
+	w.Close()
+
+	return string(<-raw)
+}
+
+// decode will unmarshal raw into a map and fail the test
+// if it's not valid JSON.
+func decode(t *testing.T, raw string) map[string]interface{} {
+	msg := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		t.Fatalf("Couldn't unmarshal the message %s. Error %s", raw, err.Error())
+	}
+	return msg
+}
+
+// TestKeepConfig will test that llogger-* keys are only
+// included in the output when llogger-keepconfig is true.
+func TestKeepConfig(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-tf": "Unix", "llogger-keepconfig": true})
+	client2 := Create(nil, Input{"llogger-tf": "Unix"})
+	client3 := Create(nil, Input{"llogger-tf": "Unix", "llogger-keepconfig": false})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+		client3.Print(Input{"message": "Testmessage3"})
+	})
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	switch {
+	case msg1["llogger-tf"] != "Unix":
+		t.Fatalf("Expected llogger-tf to be kept as Unix but got %v", msg1["llogger-tf"])
+
+	case msg1["llogger-keepconfig"] != nil:
+		t.Fatalf("Expected llogger-keepconfig to not be kept but got %v", msg1["llogger-keepconfig"])
+
+	case msg2["llogger-tf"] != nil:
+		t.Fatalf("Expected llogger-tf to not be kept without llogger-keepconfig but got %v", msg2["llogger-tf"])
+
+	case msg3["llogger-tf"] != nil || msg3["llogger-keepconfig"] != nil:
+		t.Fatalf("Expected no config keys with llogger-keepconfig false but got %s", strs[2])
+	}
+
+	// Check that the config was still applied.
+	if _, ok := msg1["time"].(float64); !ok {
+		t.Fatalf("Expected time to be Unix timestamp but got %v", msg1["time"])
+	}
+}
+
+// panicMarshaler is a type whose MarshalJSON always panics.
+type panicMarshaler struct{}
+
+func (panicMarshaler) MarshalJSON() ([]byte, error) {
+	panic("marshal boom")
+}
+
+// TestMarshalPanic will test that a value that panics during
+// marshal doesn't prevent the rest of the message from printing.
+func TestMarshalPanic(t *testing.T) {
+	client := Create(nil, Input{"service": "llogger-test"})
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1", "bad": panicMarshaler{}})
+	})
+
+	if len(strs) != 1 {
+		t.Fatalf("Expected 1 line from stdout but got %d", len(strs))
+	}
+
+	msg := decode(t, strs[0])
+	errs, _ := msg["marshalErrors"].(map[string]interface{})
+
+	switch {
+	case msg["message"] != "Testmessage1":
+		t.Fatalf("Expected message to be Testmessage1 but got %v", msg["message"])
+
+	case msg["service"] != "llogger-test":
+		t.Fatalf("Expected service to be llogger-test but got %v", msg["service"])
+
+	case msg["bad"] != "<marshal panic>":
+		t.Fatalf("Expected bad to be replaced with placeholder but got %v", msg["bad"])
+
+	case !strings.Contains(fmt.Sprint(errs["bad"]), "marshal boom"):
+		t.Fatalf("Expected marshalErrors to contain the panic for bad but got %v", msg["marshalErrors"])
+	}
+}
+
+// TestPrintContext will test that the deadline of the context
+// supplied to PrintContext is used for timeLeft.
+func TestPrintContext(t *testing.T) {
+	ctx1, cancel1 := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel1()
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel2()
+
+	client := Create(ctx1, nil)
+
+	strs := capture(t, func() {
+		client.PrintContext(ctx2, Input{"message": "Testmessage1"})
+		client.PrintContext(context.Background(), Input{"message": "Testmessage2"})
+		client.Print(Input{"message": "Testmessage3"})
+	})
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	tl1, _ := msg1["timeLeft"].(float64)
+	tl2, _ := msg2["timeLeft"].(float64)
+	tl3, _ := msg3["timeLeft"].(float64)
+
+	switch {
+	case tl1 < 0.9 || tl1 > 1.0:
+		t.Fatalf("Expected timeLeft in msg1 to be between 0.9 and 1.0 seconds. But got %f", tl1)
+
+	case tl2 < 9.9 || tl2 > 10.0:
+		t.Fatalf("Expected timeLeft in msg2 to fall back to client. But got %f", tl2)
+
+	case tl3 < 9.9 || tl3 > 10.0:
+		t.Fatalf("Expected timeLeft in msg3 to be from client. But got %f", tl3)
+	}
+}
+
+// TestPrintPrefixed will test that the prefix and suffix given
+// to PrintPrefixed only applies to that message.
+func TestPrintPrefixed(t *testing.T) {
+	client := Create(nil, Input{"llogger-prefix": "prefix: ", "llogger-suffix": " suffix"})
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1"})
+		client.PrintPrefixed("MARKER ", " END", Input{"message": "Testmessage2"})
+		client.Print(Input{"message": "Testmessage3"})
+	})
+
+	switch {
+	case !strings.HasPrefix(strs[0], "prefix: {") || !strings.HasSuffix(strs[0], "} suffix"):
+		t.Fatalf("Expected client prefix and suffix in msg1 but got %s", strs[0])
+
+	case !strings.HasPrefix(strs[1], "MARKER {") || !strings.HasSuffix(strs[1], "} END"):
+		t.Fatalf("Expected one-off prefix and suffix in msg2 but got %s", strs[1])
+
+	case !strings.HasPrefix(strs[2], "prefix: {") || !strings.HasSuffix(strs[2], "} suffix"):
+		t.Fatalf("Expected client prefix and suffix in msg3 but got %s", strs[2])
+	}
+
+	if msg := decode(t, strs[1][7:len(strs[1])-4]); msg["message"] != "Testmessage2" {
+		t.Fatalf("Expected message in msg2 to be Testmessage2 but got %v", msg["message"])
+	}
+}
+
+// TestFieldNameCollisions will test that a warning is printed
+// when two fields are configured with the same name.
+func TestFieldNameCollisions(t *testing.T) {
+	var client *Client
+	strs := capture(t, func() {
+		client = Create(nil, Input{"llogger-llfn": "level", "llogger-mfn": "level"})
+	})
+
+	if len(strs) != 1 {
+		t.Fatalf("Expected 1 line from stdout but got %d", len(strs))
+	}
+	if !strings.Contains(strs[0], "loglevel and message are both named level") {
+		t.Fatalf("Expected warning about loglevel and message colliding but got %s", strs[0])
+	}
+	if collisions := client.fieldNameCollisions(); len(collisions) != 1 {
+		t.Fatalf("Expected 1 collision but got %v", collisions)
+	}
+
+	// Check that no warning is printed without collisions.
+	strs = capture(t, func() {
+		Create(nil, Input{"llogger-llfn": "level"})
+	})
+	if strs[0] != "" {
+		t.Fatalf("Expected no output without collisions but got %s", strs[0])
+	}
+}
+
+// splitter is used to test splitting method names.
+type splitter struct{}
+
+func (s *splitter) print(client *Client) {
+	client.Print(Input{"message": "Testmessage2"})
+}
+
+// TestSplitFunc will test that the function of the resource is split
+// into package and function for plain functions and methods.
+func TestSplitFunc(t *testing.T) {
+	client := Create(nil, Input{"llogger-splitfunc": true})
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1"})
+		(&splitter{}).print(client)
+	})
+
+	res1, _ := decode(t, strs[0])["resource"].(map[string]interface{})
+	res2, _ := decode(t, strs[1])["resource"].(map[string]interface{})
+
+	switch {
+	case res1["package"] != "github.com/nuttmeister/llogger":
+		t.Fatalf("Expected package in msg1 to be github.com/nuttmeister/llogger but got %v", res1["package"])
+
+	case res1["function"] != "TestSplitFunc.func1":
+		t.Fatalf("Expected function in msg1 to be TestSplitFunc.func1 but got %v", res1["function"])
+
+	case res2["package"] != "github.com/nuttmeister/llogger":
+		t.Fatalf("Expected package in msg2 to be github.com/nuttmeister/llogger but got %v", res2["package"])
+
+	case res2["function"] != "(*splitter).print":
+		t.Fatalf("Expected function in msg2 to be (*splitter).print but got %v", res2["function"])
+	}
+
+	for name, expected := range map[string][2]string{
+		"main.main":                  {"main", "main"},
+		"github.com/a/b.(*T).Method": {"github.com/a/b", "(*T).Method"},
+		"github.com/a/b.Func.func1":  {"github.com/a/b", "Func.func1"},
+		"unknown":                    {"", "unknown"},
+	} {
+		if pkg, fn := splitFuncName(name); pkg != expected[0] || fn != expected[1] {
+			t.Fatalf("Expected %s to be split into %v but got %s and %s", name, expected, pkg, fn)
+		}
+	}
+}
+
+// TestFlatCaller will test that the resource is printed as a flat
+// caller string when enabled.
+func TestFlatCaller(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-caller": true, "llogger-shortpath": true})
+	client2 := Create(nil, Input{"llogger-caller": true, "llogger-cfn": "src", "llogger-splitfunc": true})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	caller1, _ := msg1["caller"].(string)
+	src2, _ := msg2["src"].(string)
+
+	switch {
+	case !strings.HasPrefix(caller1, "github.com/nuttmeister/llogger.TestFlatCaller.func1 (") || !strings.Contains(caller1, "/llogger_test.go:"):
+		t.Fatalf("Expected a flat caller string in msg1 but got %s", strs[0])
+
+	case msg1["resource"] != nil:
+		t.Fatalf("Expected no resource object in msg1 but got %s", strs[0])
+
+	case !strings.HasPrefix(src2, "github.com/nuttmeister/llogger.TestFlatCaller.func1 (") || msg2["caller"] != nil || msg2["resource"] != nil:
+		t.Fatalf("Expected a flat caller string in src in msg2 but got %s", strs[1])
+	}
+}
+
+// TestWithFields will test that WithFields adds fields
+// without changing the original client.
+func TestWithFields(t *testing.T) {
+	client := Create(nil, Input{"service": "llogger-test", "env": "test"})
+	child := client.WithFields(Input{"requestId": "1337", "env": "child"})
+
+	strs := capture(t, func() {
+		child.Print(Input{"message": "Testmessage1"})
+		client.Print(Input{"message": "Testmessage2"})
+	})
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	switch {
+	case msg1["service"] != "llogger-test" || msg1["requestId"] != "1337" || msg1["env"] != "child":
+		t.Fatalf("Expected service, requestId and overridden env in msg1 but got %s", strs[0])
+
+	case msg2["requestId"] != nil || msg2["env"] != "test":
+		t.Fatalf("Expected original client to be unchanged in msg2 but got %s", strs[1])
+	}
+}
+
+// TestFuncNameForPC will test that unresolvable program
+// counters give the function name unknown.
+func TestFuncNameForPC(t *testing.T) {
+	switch {
+	case funcNameForPC(0) != "unknown":
+		t.Fatalf("Expected function name for pc 0 to be unknown but got %s", funcNameForPC(0))
+
+	case funcNameForPC(^uintptr(0)) != "unknown":
+		t.Fatalf("Expected function name for invalid pc to be unknown but got %s", funcNameForPC(^uintptr(0)))
+	}
+
+	// Check that printing with an unresolvable caller doesn't panic.
+	client := Create(nil, nil)
+	strs := capture(t, func() {
+		client.print(call{skip: 100}, Input{"message": "Testmessage1"})
+	})
+
+	res, _ := decode(t, strs[0])["resource"].(map[string]interface{})
+	if res["function"] != "unknown" {
+		t.Fatalf("Expected function to be unknown for missing caller but got %v", res["function"])
+	}
+}
+
+// TestEncodeFailed will test that a message that can't be marshaled
+// results in exactly one safe line, or a call to the error handler.
+func TestEncodeFailed(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-llfn": `level"`, "service": "llogger-test"})
+
+	var handled error
+	client2 := Create(nil, Input{"llogger-onerror": func(err error) { handled = err }})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1", "bad": func() {}})
+		client2.Print(Input{"message": "Testmessage2", "bad": make(chan int)})
+	})
+
+	if len(strs) != 1 {
+		t.Fatalf("Expected exactly 1 line from stdout but got %d", len(strs))
+	}
+
+	msg := decode(t, strs[0])
+
+	switch {
+	case len(msg) != 2:
+		t.Fatalf("Expected only log level and message in the safe line but got %s", strs[0])
+
+	case msg[`level"`] != "error" || msg["message"] != "Couldn't JSON marshal the error message":
+		t.Fatalf("Expected escaped log level field and message in the safe line but got %s", strs[0])
+
+	case client1.LastError() == nil:
+		t.Fatalf("Expected LastError to be set for client1")
+
+	case handled == nil || client2.LastError() != handled:
+		t.Fatalf("Expected error handler to be called with the error recorded by LastError but got %v", handled)
+	}
+}
+
+// TestNoNewline will test that the trailing newline is only
+// removed when llogger-nonewline is set.
+func TestNoNewline(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-nonewline": true, "llogger-prefix": "prefix: ", "llogger-suffix": " suffix"})
+	client2 := Create(nil, nil)
+
+	raw1 := captureRaw(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+	})
+	raw2 := captureRaw(t, func() {
+		client2.Print(Input{"message": "Testmessage2"})
+	})
+
+	switch {
+	case !strings.HasPrefix(raw1, "prefix: {") || !strings.HasSuffix(raw1, "} suffix"):
+		t.Fatalf("Expected prefix and suffix without trailing newline but got %q", raw1)
+
+	case strings.Contains(raw1, "\n"):
+		t.Fatalf("Expected no newline but got %q", raw1)
+
+	case !strings.HasSuffix(raw2, "}\n"):
+		t.Fatalf("Expected trailing newline by default but got %q", raw2)
+	}
+}
+
+// TestNilClient will test that printing with a nil client
+// prints with the default settings instead of panicking.
+func TestNilClient(t *testing.T) {
+	var client *Client
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1"})
+		client.Error("Testmessage2", nil)
+		client.WithFields(Input{"service": "llogger-test"}).Info("Testmessage3", nil)
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	switch {
+	case len(strs) != 3:
+		t.Fatalf("Expected 3 messages but got %d", len(strs))
+
+	case msg1["message"] != "Testmessage1" || msg1["time"] == nil || msg1["resource"] == nil:
+		t.Fatalf("Expected default fields in msg1 but got %s", strs[0])
+
+	case msg2["loglevel"] != "error" || msg2["message"] != "Testmessage2":
+		t.Fatalf("Expected error log level in msg2 but got %s", strs[1])
+
+	case msg3["service"] != "llogger-test":
+		t.Fatalf("Expected service in msg3 but got %s", strs[2])
+
+	case client.Close() != nil || client.LastError() != nil:
+		t.Fatalf("Expected Close and LastError on nil client to return nil")
+	}
+}
+
+// TestReset will test that Reset removes the fields added
+// after Create but keeps the fields and field names from Create.
+func TestReset(t *testing.T) {
+	client := Create(nil, Input{"service": "llogger-test", "llogger-mfn": "msg"}).WithFields(Input{"requestId": "1337"})
+
+	strs := capture(t, func() {
+		client.Print(Input{"msg": "Testmessage1"})
+		client.Reset()
+		client.Print(Input{"msg": "Testmessage2"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	switch {
+	case msg1["requestId"] != "1337" || msg1["service"] != "llogger-test":
+		t.Fatalf("Expected requestId and service in msg1 but got %s", strs[0])
+
+	case msg2["requestId"] != nil:
+		t.Fatalf("Expected requestId to be removed in msg2 but got %s", strs[1])
+
+	case msg2["service"] != "llogger-test" || msg2["msg"] != "Testmessage2":
+		t.Fatalf("Expected service and msg field name to be kept in msg2 but got %s", strs[1])
+	}
+}
+
+// TestResetConfigKeys will test that the configuration keys read by
+// Create are not restored as fields by Reset and NewInvocation.
+func TestResetConfigKeys(t *testing.T) {
+	client := Create(nil, Input{
+		"service":             "llogger-test",
+		"llogger-memwarn":     90,
+		"llogger-memlimit":    100000,
+		"llogger-meminterval": time.Hour,
+		"llogger-shutdown":    context.Background(),
+	})
+	defer client.Close()
+
+	strs := capture(t, func() {
+		client.Reset()
+		client.Print(Input{"message": "Testmessage1"})
+		client.NewInvocation(nil).Print(Input{"message": "Testmessage2"})
+	})
+
+	for _, str := range strs {
+		msg := decode(t, str)
+		if msg["service"] != "llogger-test" {
+			t.Fatalf("Expected the data from Create but got %s", str)
+		}
+		for k := range msg {
+			if strings.HasPrefix(k, "llogger-") {
+				t.Fatalf("Expected no configuration keys but got %s", str)
+			}
+		}
+	}
+}
+
+// TestWithContext will test that a client created without a
+// context gets duration and time left from WithContext.
+func TestWithContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client := Create(nil, Input{"service": "llogger-test"})
+	child := client.WithContext(ctx)
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1"})
+		child.Print(Input{"message": "Testmessage2"})
+		child.WithContext(nil).Print(Input{"message": "Testmessage3"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	timeLeft, _ := msg2["timeLeft"].(float64)
+
+	switch {
+	case msg1["timeLeft"] != nil || msg1["duration"] != nil:
+		t.Fatalf("Expected no time left in msg1 but got %s", strs[0])
+
+	case timeLeft <= 2 || timeLeft > 3 || msg2["duration"] == nil || msg2["service"] != "llogger-test":
+		t.Fatalf("Expected time left from the context in msg2 but got %s", strs[1])
+
+	case msg3["timeLeft"] != nil:
+		t.Fatalf("Expected no time left in msg3 but got %s", strs[2])
+	}
+}
+
+// TestCreateWithoutDeadline will test that the error printed by Create
+// for a context without a deadline uses the configured field names,
+// messages and format.
+func TestCreateWithoutDeadline(t *testing.T) {
+	strs := capture(t, func() {
+		Create(context.Background(), Input{
+			"llogger-llfn":   "level",
+			"llogger-mfn":    "msg",
+			"llogger-tfn":    "ts",
+			"llogger-cm":     "fatal",
+			"llogger-tf":     "Unix",
+			"llogger-epoch":  true,
+			"llogger-prefix": "PRE ",
+		})
+	})
+
+	if len(strs) != 1 || !strings.HasPrefix(strs[0], "PRE ") {
+		t.Fatalf("Expected 1 message with the prefix but got %q", strs)
+	}
+	msg := decode(t, strings.TrimPrefix(strs[0], "PRE "))
+	_, isUnix := msg["ts"].(float64)
+
+	switch {
+	case msg["level"] != "fatal" || msg["msg"] != "Couldn't get Deadline from context":
+		t.Fatalf("Expected the configured log level and message field names but got %s", strs[0])
+
+	case !isUnix || msg["epoch"] == nil:
+		t.Fatalf("Expected the configured time format and optional fields but got %s", strs[0])
+
+	case msg["loglevel"] != nil || msg["message"] != nil || msg["time"] != nil || msg["timeLeft"] != nil:
+		t.Fatalf("Expected no default field names or time left but got %s", strs[0])
+
+	case msg["llogger-llfn"] != nil || msg["llogger-prefix"] != nil:
+		t.Fatalf("Expected no configuration keys but got %s", strs[0])
+	}
+}
+
+// TestFieldNames will test that FieldNames returns the configured
+// field names and the defaults for the rest.
+func TestFieldNames(t *testing.T) {
+	client1 := Create(nil, Input{
+		"llogger-tfn":  "ts",
+		"llogger-llfn": "level",
+		"llogger-mfn":  "msg",
+		"llogger-dfn":  "elapsed",
+		"llogger-tlfn": "remaining",
+		"llogger-rfn":  "source",
+	})
+	client2 := Create(nil, Input{"llogger-mfn": "msg"})
+
+	names1 := client1.FieldNames()
+	names2 := client2.FieldNames()
+
+	switch {
+	case names1 != FieldNames{Time: "ts", LogLevel: "level", Message: "msg", Duration: "elapsed", TimeLeft: "remaining", Resource: "source"}:
+		t.Fatalf("Expected the configured field names for client1 but got %+v", names1)
+
+	case names2 != FieldNames{Time: "time", LogLevel: "loglevel", Message: "msg", Duration: "duration", TimeLeft: "timeLeft", Resource: "resource"}:
+		t.Fatalf("Expected the default field names except message for client2 but got %+v", names2)
+	}
+}
+
+// TestNewInvocation will test that NewInvocation gives each invocation
+// its own duration and time left but keeps the data from Create.
+func TestNewInvocation(t *testing.T) {
+	ctx1, cancel1 := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel1()
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel2()
+
+	client := Create(ctx1, Input{"service": "llogger-test"})
+	time.Sleep(100 * time.Millisecond)
+
+	strs := capture(t, func() {
+		client.Print(Input{"message": "Testmessage1"})
+		client.WithFields(Input{"requestId": "1337"}).NewInvocation(ctx2).Print(Input{"message": "Testmessage2"})
+		client.NewInvocation(nil).Print(Input{"message": "Testmessage3"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	duration1, _ := msg1["duration"].(float64)
+	duration2, _ := msg2["duration"].(float64)
+	timeLeft2, _ := msg2["timeLeft"].(float64)
+
+	switch {
+	case duration1 < 0.1:
+		t.Fatalf("Expected the duration of the client to be at least 0.1 in msg1 but got %s", strs[0])
+
+	case msg2["duration"] == nil || duration2 >= 0.1 || timeLeft2 <= 1.5 || timeLeft2 > 2:
+		t.Fatalf("Expected the duration and time left of the invocation in msg2 but got %s", strs[1])
+
+	case msg2["service"] != "llogger-test" || msg2["requestId"] != nil:
+		t.Fatalf("Expected only the data from Create in msg2 but got %s", strs[1])
+
+	case msg3["duration"] != nil || msg3["timeLeft"] != nil || msg3["service"] != "llogger-test":
+		t.Fatalf("Expected no duration or time left without a context in msg3 but got %s", strs[2])
+	}
+}
+
+// TestNewInvocationClose will test that invocations can each be closed
+// and get their own summary and JSON array.
+func TestNewInvocationClose(t *testing.T) {
+	buf := &syncBuffer{}
+	client := Create(nil, Input{"llogger-writer": buf, "llogger-summary": true, "llogger-array": true})
+
+	for i := 1; i <= 3; i++ {
+		inv := client.NewInvocation(nil)
+		inv.Info(fmt.Sprintf("Testmessage%d", i), nil)
+		inv.Info("Done", nil)
+		inv.Close()
+	}
+
+	strs := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(strs) != 3 {
+		t.Fatalf("Expected one array per invocation but got %q", strs)
+	}
+	for i, str := range strs {
+		msgs := []map[string]interface{}{}
+		if err := json.Unmarshal([]byte(str), &msgs); err != nil {
+			t.Fatalf("Expected a JSON array for invocation %d but got %s", i+1, str)
+		}
+
+		switch {
+		case len(msgs) != 3 || msgs[0]["message"] != fmt.Sprintf("Testmessage%d", i+1):
+			t.Fatalf("Expected the messages and summary of invocation %d but got %s", i+1, str)
+
+		case msgs[2]["message"] != "Summary" || !reflect.DeepEqual(msgs[2]["counts"], map[string]interface{}{"info": float64(2)}):
+			t.Fatalf("Expected the summary of only invocation %d but got %s", i+1, str)
+		}
+	}
+}
+
+// BenchmarkCreate benchmarks creating a client for each invocation.
+func BenchmarkCreate(b *testing.B) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	inp := Input{"service": "llogger-test", "team": "llogger", "llogger-region": true}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Create(ctx, inp)
+	}
+}
+
+// BenchmarkNewInvocation benchmarks reusing a client with
+// NewInvocation for each invocation, for comparison.
+func BenchmarkNewInvocation(b *testing.B) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client := Create(nil, Input{"service": "llogger-test", "team": "llogger", "llogger-region": true})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		client.NewInvocation(ctx)
+	}
+}
+
+// TestEpochTruncate will test that epoch timestamps are truncated
+// to the unit set with llogger-epochtrunc.
+func TestEpochTruncate(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-tf": "UnixMilli", "llogger-epochtrunc": "1s", "llogger-epoch": true})
+	client2 := Create(nil, Input{"llogger-tf": "UnixMilli"})
+	client3 := Create(nil, Input{"llogger-tf": "UnixNano", "llogger-epochtrunc": time.Millisecond})
+	at := time.Date(2019, 1, 2, 3, 4, 5, 678901234, time.UTC)
+
+	strs := capture(t, func() {
+		client1.PrintAt(at, Input{"message": "Testmessage1"})
+		client2.PrintAt(at, Input{"message": "Testmessage2"})
+		client3.PrintAt(at, Input{"message": "Testmessage3"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	time1, _ := msg1["time"].(float64)
+
+	switch {
+	case int64(time1)%1000 != 0 || int64(time1) != at.Unix()*1000:
+		t.Fatalf("Expected time to be truncated to a multiple of 1000 in msg1 but got %s", strs[0])
+
+	case msg1["epoch"] != time1 || msg1["llogger-epochtrunc"] != nil:
+		t.Fatalf("Expected epoch to be truncated like time in msg1 but got %s", strs[0])
+
+	case msg2["time"] != float64(at.UnixNano()/1e6):
+		t.Fatalf("Expected time in milliseconds without truncation in msg2 but got %s", strs[1])
+
+	case !strings.Contains(strs[2], `"time":1546398245678000000`):
+		t.Fatalf("Expected time to be truncated to milliseconds in msg3 but got %s", strs[2])
+	}
+}
+
+// TestPrintAt will test that PrintAt uses the supplied time
+// for the time field but the current time for time left.
+func TestPrintAt(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client := Create(ctx, Input{"llogger-tf": time.RFC3339Nano, "llogger-epoch": true})
+	at := time.Date(2019, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+	strs := capture(t, func() {
+		client.PrintAt(at, Input{"message": "Testmessage1"})
+	})
+	msg := decode(t, strs[0])
+	timeLeft, _ := msg["timeLeft"].(float64)
+
+	switch {
+	case msg["time"] != at.Format(time.RFC3339Nano):
+		t.Fatalf("Expected time to be %s but got %s", at.Format(time.RFC3339Nano), strs[0])
+
+	case msg["epoch"] != float64(at.UnixNano()/1e6):
+		t.Fatalf("Expected epoch to be from the supplied time but got %s", strs[0])
+
+	case timeLeft <= 2 || timeLeft > 3:
+		t.Fatalf("Expected time left to be based on the current time but got %s", strs[0])
+	}
+}
+
+// printFromHelper prints inp with the call site of its caller
+// as resource, like a framework would.
+func printFromHelper(client *Client, inp Input) {
+	pc, _, _, _ := runtime.Caller(1)
+	client.PrintFrom(pc, inp)
+}
+
+// TestPrintFrom will test that PrintFrom resolves the supplied
+// program counter into the resource.
+func TestPrintFrom(t *testing.T) {
+	client := Create(nil, nil)
+	pc, file, row, _ := runtime.Caller(0)
+
+	strs := capture(t, func() {
+		client.PrintFrom(pc, Input{"message": "Testmessage1"})
+		printFromHelper(client, Input{"message": "Testmessage2"})
+		client.PrintFrom(0, Input{"message": "Testmessage3"})
+	})
+	res1, _ := decode(t, strs[0])["resource"].(map[string]interface{})
+	res2, _ := decode(t, strs[1])["resource"].(map[string]interface{})
+	res3, _ := decode(t, strs[2])["resource"].(map[string]interface{})
+
+	switch {
+	case res1["function"] != "github.com/nuttmeister/llogger.TestPrintFrom" || res1["file"] != file || res1["row"] != float64(row):
+		t.Fatalf("Expected the resource of the supplied pc in msg1 but got %s", strs[0])
+
+	case res2["function"] != "github.com/nuttmeister/llogger.TestPrintFrom.func1" || res2["file"] != file:
+		t.Fatalf("Expected the caller of the helper as resource in msg2 but got %s", strs[1])
+
+	case res3["function"] != "github.com/nuttmeister/llogger.TestPrintFrom.func1":
+		t.Fatalf("Expected the caller of PrintFrom as resource in msg3 but got %s", strs[2])
+	}
+}
+
+// callerAtHelper returns the Resource of its caller.
+func callerAtHelper(client *Client) Resource {
+	return client.CallerAt(1)
+}
+
+// TestCallerAt will test that CallerAt returns the frame of
+// the caller and handles out of range skips.
+func TestCallerAt(t *testing.T) {
+	client := Create(nil, Input{"llogger-shortpath": true})
+
+	_, _, row, _ := runtime.Caller(0)
+	res0 := client.CallerAt(0)
+	res1 := callerAtHelper(client)
+	res2 := client.CallerAt(1000)
+	res3 := client.CallerAt(-1)
+
+	switch {
+	case !strings.HasSuffix(res0.Function, ".TestCallerAt") || res0.Row != row+1:
+		t.Fatalf("Expected CallerAt(0) to be TestCallerAt row %d but got %+v", row+1, res0)
+
+	case !strings.HasSuffix(res0.File, "/llogger_test.go") || strings.Count(res0.File, "/") != 1:
+		t.Fatalf("Expected the short path of llogger_test.go but got %s", res0.File)
+
+	case !strings.HasSuffix(res1.Function, ".TestCallerAt") || res1.Row != row+2:
+		t.Fatalf("Expected CallerAt(1) in a helper to be TestCallerAt row %d but got %+v", row+2, res1)
+
+	case res2.Function != "unknown" || res2.File != "" || res3.Function != "unknown":
+		t.Fatalf("Expected out of range skips to be unknown but got %+v and %+v", res2, res3)
+	}
+}
+
+// TestMaxPrefix will test that the prefix and suffix are
+// truncated to llogger-maxprefix.
+func TestMaxPrefix(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-maxprefix": 8, "llogger-prefix": strings.Repeat("p", 100) + " ", "llogger-suffix": " åäö"})
+	client2 := Create(nil, Input{"llogger-prefix": strings.Repeat("p", 100) + " "})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client1.SetPrefix("set: ")
+		client1.SetSuffix(" åäöåäö")
+		client1.Print(Input{"message": "Testmessage2"})
+		client2.Print(Input{"message": "Testmessage3"})
+	})
+
+	switch {
+	case !strings.HasPrefix(strs[0], "pppppppp{") || !strings.HasSuffix(strs[0], "} åäö"):
+		t.Fatalf("Expected prefix to be truncated to 8 bytes in msg1 but got %s", strs[0])
+
+	case !strings.HasPrefix(strs[1], "set: {") || !strings.HasSuffix(strs[1], "} åäö"):
+		t.Fatalf("Expected suffix to be truncated at a character in msg2 but got %s", strs[1])
+
+	case !strings.HasPrefix(strs[2], strings.Repeat("p", 100)+" {"):
+		t.Fatalf("Expected prefix to not be truncated without a limit in msg3 but got %s", strs[2])
+	}
+}
+
+// TestSeparators will test that the separators are added between
+// the prefix, message and suffix when configured.
+func TestSeparators(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-prefix": "pre", "llogger-suffix": "suf", "llogger-presep": " | ", "llogger-sufsep": " # "})
+	client2 := Create(nil, Input{"llogger-presep": " | ", "llogger-sufsep": " # "})
+	client3 := Create(nil, Input{"llogger-prefix": "pre", "llogger-suffix": "suf"})
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client1.PrintPrefixed("other", "", Input{"message": "Testmessage2"})
+		client2.Print(Input{"message": "Testmessage3"})
+		client3.Print(Input{"message": "Testmessage4"})
+	})
+
+	switch {
+	case !strings.HasPrefix(strs[0], "pre | {") || !strings.HasSuffix(strs[0], "} # suf"):
+		t.Fatalf("Expected separators around the message in msg1 but got %s", strs[0])
+
+	case !strings.HasPrefix(strs[1], "other | {") || !strings.HasSuffix(strs[1], "}"):
+		t.Fatalf("Expected only the prefix separator in msg2 but got %s", strs[1])
+
+	case !strings.HasPrefix(strs[2], "{") || !strings.HasSuffix(strs[2], "}"):
+		t.Fatalf("Expected no separators without prefix and suffix in msg3 but got %s", strs[2])
+
+	case !strings.HasPrefix(strs[3], "pre{") || !strings.HasSuffix(strs[3], "}suf"):
+		t.Fatalf("Expected no separators by default in msg4 but got %s", strs[3])
+	}
+}
+
+// TestMerge will test that Merge combines the data of two
+// clients and keeps the configuration of the first.
+func TestMerge(t *testing.T) {
+	parent := Create(nil, Input{"service": "parent", "env": "test", "llogger-mfn": "msg"})
+	lib := Create(nil, Input{"service": "lib", "library": "mylib", "llogger-mfn": "text"})
+	merged := lib.Merge(parent)
+
+	strs := capture(t, func() {
+		merged.Print(Input{"text": "Testmessage1"})
+		merged.Reset()
+		merged.Print(Input{"text": "Testmessage2"})
+		lib.Print(Input{"text": "Testmessage3"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	switch {
+	case msg1["service"] != "lib" || msg1["library"] != "mylib" || msg1["env"] != "test":
+		t.Fatalf("Expected fields from both clients with service from lib in msg1 but got %s", strs[0])
+
+	case msg1["text"] != "Testmessage1" || msg1["msg"] != nil:
+		t.Fatalf("Expected the message field name of lib in msg1 but got %s", strs[0])
+
+	case msg2["env"] != "test":
+		t.Fatalf("Expected Reset to keep the merged fields in msg2 but got %s", strs[1])
+
+	case msg3["env"] != nil:
+		t.Fatalf("Expected lib to be unchanged in msg3 but got %s", strs[2])
+	}
+}
+
+// TestNewline will test that messages end with the line
+// terminator set with llogger-newline, also when buffered.
+func TestNewline(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-newline": "\r\n"})
+	buf := &syncBuffer{}
+	client2 := Create(nil, Input{"llogger-newline": "\r\n", "llogger-writer": buf, "llogger-buffered": true})
+
+	raw := captureRaw(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+	})
+	client2.Print(Input{"message": "Testmessage2"})
+	client2.Print(Input{"message": "Testmessage3"})
+	client2.Close()
+
+	switch {
+	case !strings.HasSuffix(raw, "}\r\n") || strings.Count(raw, "\n") != 1:
+		t.Fatalf("Expected message to end with \\r\\n but got %q", raw)
+
+	case strings.Count(buf.String(), "}\r\n") != 2 || strings.Count(buf.String(), "\n") != 2:
+		t.Fatalf("Expected buffered messages to end with \\r\\n but got %q", buf.String())
+	}
+}
+
+// TestBuild will test that Build returns the same fields as
+// Print without printing anything.
+func TestBuild(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client := Create(ctx, Input{"service": "llogger-test", "llogger-sample": 0})
+
+	var out Input
+	raw := captureRaw(t, func() {
+		out = client.Build(Input{"loglevel": "info", "message": "Testmessage1"})
+	})
+	_, _, row, _ := runtime.Caller(0)
+	res, _ := out["resource"].(Resource)
+
+	switch {
+	case raw != "":
+		t.Fatalf("Expected Build to not print anything but got %q", raw)
+
+	case out["message"] != "Testmessage1" || out["loglevel"] != "info" || out["service"] != "llogger-test":
+		t.Fatalf("Expected message, loglevel and service but got %v", out)
+
+	case out["time"] == nil || out["duration"] == nil || out["timeLeft"] == nil:
+		t.Fatalf("Expected time, duration and timeLeft but got %v", out)
+
+	case !strings.HasSuffix(res.Function, ".TestBuild.func1") || res.Row != row-2:
+		t.Fatalf("Expected resource to be the caller of Build but got %+v", out["resource"])
+	}
+}
+
+// TestEmpty will test Print with an empty Input under each
+// llogger-empty policy.
+func TestEmpty(t *testing.T) {
+	client1 := Create(nil, nil)
+	client2 := Create(nil, Input{"llogger-empty": "warn"})
+	client3 := Create(nil, Input{"llogger-empty": "minimal"})
+
+	strs1 := capture(t, func() { client1.Print(nil) })
+	strs2 := capture(t, func() { client2.Print(Input{}) })
+	raw3 := captureRaw(t, func() { client3.Print(nil) })
+	strs4 := capture(t, func() { client3.Print(Input{"message": "Testmessage4"}) })
+	msg1 := decode(t, strs1[0])
+	msg2 := decode(t, strs2[0])
+	msg4 := decode(t, strs4[0])
+	res2, _ := msg2["resource"].(map[string]interface{})
+
+	switch {
+	case msg1["time"] == nil || msg1["resource"] == nil || msg1["loglevel"] != nil || msg1["message"] != nil:
+		t.Fatalf("Expected only the built in fields by default but got %s", strs1[0])
+
+	case msg2["loglevel"] != "warning" || msg2["message"] != "Empty log message":
+		t.Fatalf("Expected a warning about the empty log call with warn but got %s", strs2[0])
+
+	case res2 == nil || res2["function"] != "github.com/nuttmeister/llogger.TestEmpty.func2":
+		t.Fatalf("Expected the resource of the caller of Print with warn but got %s", strs2[0])
+
+	case raw3 != "{}\n":
+		t.Fatalf("Expected {} with minimal but got %q", raw3)
+
+	case msg4["message"] != "Testmessage4":
+		t.Fatalf("Expected a non empty Input to be printed as is with minimal but got %s", strs4[0])
+	}
+}
+
+// TestDurationStrings will test that duration and timeLeft are
+// printed as Go duration strings when enabled.
+func TestDurationStrings(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client1 := Create(ctx, Input{"llogger-durations": "string"})
+	client2 := Create(ctx, nil)
+	defer client1.Close()
+	defer client2.Close()
+
+	strs := capture(t, func() {
+		client1.Print(Input{"message": "Testmessage1"})
+		client2.Print(Input{"message": "Testmessage2"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	timeLeft, _ := msg1["timeLeft"].(string)
+	left, err := time.ParseDuration(timeLeft)
+	durationStr, _ := msg1["duration"].(string)
+	duration, durationErr := time.ParseDuration(durationStr)
+	_, numeric := msg2["timeLeft"].(float64)
+
+	switch {
+	case err != nil || left <= 59*time.Second || left > time.Minute:
+		t.Fatalf("Expected timeLeft as a duration string in msg1 but got %s", strs[0])
+
+	case durationErr != nil || duration >= time.Second:
+		t.Fatalf("Expected duration as a duration string in msg1 but got %s", strs[0])
+
+	case !numeric:
+		t.Fatalf("Expected numeric timeLeft by default in msg2 but got %s", strs[1])
+	}
+}
+
+// TestKeepContext will test that a context without a deadline is
+// kept for its cancellation and values when enabled.
+func TestKeepContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "x-amzn-trace-id", "Root=1-ctx"))
+	defer cancel()
+
+	client1 := Create(ctx, Input{"llogger-keepcontext": true, "llogger-xray": true})
+	var client2 *Client
+	strs := capture(t, func() {
+		client2 = Create(ctx, nil)
+		client1.Print(Input{"message": "Testmessage1"})
+		sub, subCancel := client1.SubBudget(0.5)
+		defer subCancel()
+		if _, has := sub.Deadline(); has {
+			t.Fatalf("Expected no deadline for the sub budget without a deadline")
+		}
+
+		stop := client1.Heartbeat(time.Hour, "Still working")
+		cancel()
+		stopped := make(chan struct{})
+		go func() {
+			stop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the heartbeat to stop when the context is cancelled")
+		}
+		<-sub.Done()
+	})
+
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 messages but got %q", strs)
+	}
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	switch {
+	case msg1["message"] != "Couldn't get Deadline from context" || client2.context != nil:
+		t.Fatalf("Expected the context to be dropped by default but got %s", strs[0])
+
+	case msg2["message"] != "Testmessage1" || msg2["timeLeft"] != nil || msg2["duration"] != nil:
+		t.Fatalf("Expected no duration and timeLeft without a deadline in msg2 but got %s", strs[1])
+
+	case msg2["traceId"] != "1-ctx":
+		t.Fatalf("Expected the values of the context to be used in msg2 but got %s", strs[1])
+	}
+}
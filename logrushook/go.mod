@@ -0,0 +1,10 @@
+module github.com/nuttmeister/llogger/logrushook
+
+go 1.12
+
+require (
+	github.com/nuttmeister/llogger v0.0.0-00010101000000-000000000000
+	github.com/sirupsen/logrus v1.9.3
+)
+
+replace github.com/nuttmeister/llogger => ../
@@ -0,0 +1,81 @@
+// Package logrushook provides a logrus hook that prints logrus entries
+// with llogger, so existing logrus call sites can keep being used while
+// printing in the llogger format.
+package logrushook
+
+import (
+	"github.com/nuttmeister/llogger"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultLevelNames maps the logrus levels to the llogger log level
+// strings used by default.
+var DefaultLevelNames = map[logrus.Level]string{
+	logrus.PanicLevel: "error",
+	logrus.FatalLevel: "error",
+	logrus.ErrorLevel: "error",
+	logrus.WarnLevel:  "warning",
+	logrus.InfoLevel:  "info",
+	logrus.DebugLevel: "debug",
+	logrus.TraceLevel: "trace",
+}
+
+// Hook is a logrus.Hook that prints all entries with an llogger client.
+// Since logrus still writes the entry to its own output, set the output
+// of the logrus logger to ioutil.Discard to only get the llogger output.
+type Hook struct {
+	client *llogger.Client
+
+	// LevelNames maps the logrus levels to llogger log level strings.
+	// Levels missing from LevelNames will use the logrus level name.
+	LevelNames map[logrus.Level]string
+}
+
+// New takes client and returns a *Hook that prints with client using
+// DefaultLevelNames for the log levels.
+// Returns *Hook.
+func New(client *llogger.Client) *Hook {
+	names := map[logrus.Level]string{}
+	for k, v := range DefaultLevelNames {
+		names[k] = v
+	}
+
+	return &Hook{client: client, LevelNames: names}
+}
+
+// Levels returns all logrus levels since all entries should be printed.
+// Returns []logrus.Level.
+func (h *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire prints entry with the llogger client. The entry data will be
+// used as fields and if the logrus logger reports the caller it's added
+// in the caller field since the llogger resource will point to logrus.
+// Returns error.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	inp := llogger.Input{}
+	for k, v := range entry.Data {
+		// Errors are marshaled to {} so use the error message instead.
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		inp[k] = v
+	}
+
+	if entry.HasCaller() {
+		inp["caller"] = map[string]interface{}{
+			"function": entry.Caller.Function,
+			"file":     entry.Caller.File,
+			"row":      entry.Caller.Line,
+		}
+	}
+
+	level, ok := h.LevelNames[entry.Level]
+	if !ok {
+		level = entry.Level.String()
+	}
+
+	h.client.Log(level, entry.Message, inp)
+	return nil
+}
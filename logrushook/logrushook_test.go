@@ -0,0 +1,81 @@
+package logrushook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nuttmeister/llogger"
+	"github.com/sirupsen/logrus"
+)
+
+// TestHook will test that logrus entries are printed by llogger.
+func TestHook(t *testing.T) {
+	client := llogger.Create(nil, llogger.Input{"service": "llogger-test"})
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetLevel(logrus.DebugLevel)
+	logger.AddHook(New(client))
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Couldn't create new Pipe files. Error %s", err.Error())
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	raw := make(chan []byte)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		raw <- buf.Bytes()
+	}()
+
+	logger.WithFields(logrus.Fields{"requestId": "1337", "err": errors.New("flux capacitor")}).Warn("Testmessage1")
+	logger.Debug("Testmessage2")
+	w.Close()
+
+	strs := strings.Split(strings.TrimSuffix(string(<-raw), "\n"), "\n")
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 lines from stdout but got %d", len(strs))
+	}
+
+	msg1 := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(strs[0]), &msg1); err != nil {
+		t.Fatalf("Couldn't unmarshal the message in msg1. Error %s", err.Error())
+	}
+	msg2 := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(strs[1]), &msg2); err != nil {
+		t.Fatalf("Couldn't unmarshal the message in msg2. Error %s", err.Error())
+	}
+
+	switch {
+	case msg1["loglevel"] != "warning":
+		t.Fatalf("Expected loglevel in msg1 to be warning but got %v", msg1["loglevel"])
+
+	case msg1["message"] != "Testmessage1":
+		t.Fatalf("Expected message in msg1 to be Testmessage1 but got %v", msg1["message"])
+
+	case msg1["requestId"] != "1337":
+		t.Fatalf("Expected requestId in msg1 to be 1337 but got %v", msg1["requestId"])
+
+	case msg1["err"] != "flux capacitor":
+		t.Fatalf("Expected err in msg1 to be flux capacitor but got %v", msg1["err"])
+
+	case msg1["service"] != "llogger-test":
+		t.Fatalf("Expected service in msg1 to be llogger-test but got %v", msg1["service"])
+
+	case msg2["loglevel"] != "debug":
+		t.Fatalf("Expected loglevel in msg2 to be debug but got %v", msg2["loglevel"])
+
+	case msg2["message"] != "Testmessage2":
+		t.Fatalf("Expected message in msg2 to be Testmessage2 but got %v", msg2["message"])
+	}
+}
@@ -0,0 +1,89 @@
+package llogger
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// setMemoryMonitor will read the memory monitor configuration and
+// return a function that starts the monitor if llogger-memwarn is set
+// to a percentage. The memory limit is read in megabytes from
+// llogger-memlimit or the AWS_LAMBDA_FUNCTION_MEMORY_SIZE environment
+// variable and the heap is sampled every llogger-meminterval, which
+// defaults to one second. The returned function does nothing if no
+// limit is known. The configuration is read before the data of the
+// client is saved, while the monitor is started once it's configured.
+// Returns func().
+func (l *Client) setMemoryMonitor() func() {
+	pct, ok := l.configFloat("llogger-memwarn")
+	limit, hasLimit := l.configInt("llogger-memlimit")
+	interval, hasInterval := l.configDuration("llogger-meminterval")
+
+	if !hasLimit {
+		limit, _ = strconv.ParseInt(os.Getenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE"), 10, 64)
+	}
+	if !hasInterval || interval <= 0 {
+		interval = time.Second
+	}
+	if !ok || pct <= 0 || limit <= 0 {
+		return func() {}
+	}
+
+	return func() { l.startMemoryMonitor(pct, limit, interval) }
+}
+
+// startMemoryMonitor will start the memory monitor warning when the
+// heap usage crosses pct percent of limit megabytes, sampled every
+// interval.
+func (l *Client) startMemoryMonitor(pct float64, limit int64, interval time.Duration) {
+	threshold := uint64(float64(limit*1024*1024) * pct / 100)
+	m := &monitor{stop: make(chan struct{}), done: make(chan struct{})}
+	l.memMonitor = m
+
+	go func() {
+		defer close(m.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// Only warn when crossing the threshold, not on every sample.
+		warned := false
+		stats := &runtime.MemStats{}
+		for {
+			select {
+			case <-ticker.C:
+				runtime.ReadMemStats(stats)
+				switch {
+				case stats.HeapAlloc >= threshold && !warned:
+					warned = true
+					l.print(call{skip: 1}, Input{
+						l.llfn:        l.wm,
+						l.mfn:         "Heap usage is above " + strconv.FormatFloat(pct, 'f', -1, 64) + "% of the memory limit",
+						"heapBytes":   stats.HeapAlloc,
+						"limitBytes":  limit * 1024 * 1024,
+						"heapPercent": float64(stats.HeapAlloc) / float64(limit*1024*1024) * 100,
+					})
+
+				case stats.HeapAlloc < threshold:
+					warned = false
+				}
+
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopMemoryMonitor will stop the memory monitor of l
+// if it's running and wait for it to exit.
+func (l *Client) stopMemoryMonitor() {
+	m := l.memMonitor
+	if m == nil {
+		return
+	}
+
+	m.halt()
+}
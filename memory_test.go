@@ -0,0 +1,41 @@
+package llogger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMemoryMonitor will test that a warning is printed when
+// the heap usage crosses the percentage of a low memory limit.
+func TestMemoryMonitor(t *testing.T) {
+	buf := &syncBuffer{}
+	client := Create(nil, Input{
+		"llogger-writer":      buf,
+		"llogger-memwarn":     1,
+		"llogger-memlimit":    1,
+		"llogger-meminterval": 5 * time.Millisecond,
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	client.Close()
+
+	strs := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(strs) != 1 {
+		t.Fatalf("Expected exactly 1 memory warning but got %d lines: %s", len(strs), buf.String())
+	}
+
+	msg := decode(t, strs[0])
+	heap, _ := msg["heapBytes"].(float64)
+
+	switch {
+	case msg["loglevel"] != "warning":
+		t.Fatalf("Expected loglevel to be warning but got %v", msg["loglevel"])
+
+	case !strings.Contains(msg["message"].(string), "1% of the memory limit"):
+		t.Fatalf("Expected message about memory limit but got %v", msg["message"])
+
+	case heap <= 0 || msg["limitBytes"] != float64(1024*1024):
+		t.Fatalf("Expected heapBytes and limitBytes to be set but got %s", strs[0])
+	}
+}
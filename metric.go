@@ -0,0 +1,48 @@
+package llogger
+
+import (
+	"math"
+)
+
+// metricUnits are the allowed units of Metric, the CloudWatch units.
+var metricUnits = map[string]bool{
+	"Seconds": true, "Microseconds": true, "Milliseconds": true,
+	"Bytes": true, "Kilobytes": true, "Megabytes": true, "Gigabytes": true, "Terabytes": true,
+	"Bits": true, "Kilobits": true, "Megabits": true, "Gigabits": true, "Terabits": true,
+	"Percent": true, "Count": true, "None": true,
+	"Bytes/Second": true, "Kilobytes/Second": true, "Megabytes/Second": true, "Gigabytes/Second": true, "Terabytes/Second": true,
+	"Bits/Second": true, "Kilobits/Second": true, "Megabits/Second": true, "Gigabits/Second": true, "Terabits/Second": true,
+	"Count/Second": true,
+}
+
+// Metric prints a metric with log level info and name as message, for
+// pipelines that scrape metrics from logs. The name, value and unit are
+// set in the metric.name, metric.value and metric.unit fields, which
+// can't be overridden by inp. An empty unit means None. If the unit isn't
+// one of the CloudWatch units, such as Count, Milliseconds or Bytes, or
+// the value is NaN or infinite, the message is printed with the critical
+// log level and the metricError field instead, so that it's not lost.
+func (l *Client) Metric(name string, value float64, unit string, inp Input) {
+	l = l.orDefault()
+	if unit == "" {
+		unit = "None"
+	}
+
+	data := l.leveled("info", name, inp)
+	data["metric.name"] = name
+	data["metric.value"] = value
+	data["metric.unit"] = unit
+
+	switch {
+	case !metricUnits[unit]:
+		data[l.llfn] = l.cm
+		data["metricError"] = "Unknown metric unit " + unit
+
+	case math.IsNaN(value) || math.IsInf(value, 0):
+		data[l.llfn] = l.cm
+		data["metric.value"] = nil
+		data["metricError"] = "Metric value isn't a finite number"
+	}
+
+	l.print(call{skip: 2}, data)
+}
@@ -0,0 +1,40 @@
+package llogger
+
+import (
+	"math"
+	"testing"
+)
+
+// TestMetric will test that metrics are printed with the metric
+// fields and that invalid metrics are printed as errors.
+func TestMetric(t *testing.T) {
+	client := Create(nil, Input{"service": "llogger-test"})
+
+	strs := capture(t, func() {
+		client.Metric("ProcessedRecords", 42, "Count", Input{"metric.name": "Overridden", "batch": "1337"})
+		client.Metric("Latency", 1.5, "", nil)
+		client.Metric("Latency", 1.5, "Fortnights", nil)
+		client.Metric("Latency", math.NaN(), "Milliseconds", nil)
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+	msg4 := decode(t, strs[3])
+
+	switch {
+	case msg1["metric.name"] != "ProcessedRecords" || msg1["metric.value"] != float64(42) || msg1["metric.unit"] != "Count":
+		t.Fatalf("Expected the metric fields in msg1 but got %s", strs[0])
+
+	case msg1["loglevel"] != "info" || msg1["message"] != "ProcessedRecords" || msg1["batch"] != "1337" || msg1["service"] != "llogger-test":
+		t.Fatalf("Expected log level info and the fields of inp in msg1 but got %s", strs[0])
+
+	case msg2["metric.unit"] != "None" || msg2["metric.value"] != 1.5:
+		t.Fatalf("Expected unit None for an empty unit in msg2 but got %s", strs[1])
+
+	case msg3["loglevel"] != "error" || msg3["metricError"] == nil || msg3["metric.unit"] != "Fortnights":
+		t.Fatalf("Expected metricError for an unknown unit in msg3 but got %s", strs[2])
+
+	case msg4["loglevel"] != "error" || msg4["metricError"] == nil || msg4["metric.value"] != nil:
+		t.Fatalf("Expected metricError for NaN in msg4 but got %s", strs[3])
+	}
+}
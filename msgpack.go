@@ -0,0 +1,146 @@
+package llogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+// jsonToMsgpack takes the JSON document raw and returns it encoded
+// with MessagePack. Going through JSON means that all values are
+// encoded exactly as they would be in the JSON output, including
+// struct tags and MarshalJSON methods. The top level keys in first
+// come first, in that order, like in the JSON output.
+// Returns []byte and error.
+func jsonToMsgpack(raw []byte, first []string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if m, ok := v.(map[string]interface{}); ok {
+		packMap(buf, m, first)
+	} else {
+		packValue(buf, v)
+	}
+	return buf.Bytes(), nil
+}
+
+// packValue will write v encoded with MessagePack to buf. v must
+// be a value decoded from JSON with UseNumber.
+func packValue(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			packInt(buf, i)
+			return
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			buf.WriteByte(0xcf)
+			binary.Write(buf, binary.BigEndian, u)
+			return
+		}
+		f, _ := v.Float64()
+		buf.WriteByte(0xcb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+
+	case string:
+		n := len(v)
+		switch {
+		case n < 32:
+			buf.WriteByte(0xa0 | byte(n))
+		case n <= math.MaxUint8:
+			buf.Write([]byte{0xd9, byte(n)})
+		case n <= math.MaxUint16:
+			buf.WriteByte(0xda)
+			binary.Write(buf, binary.BigEndian, uint16(n))
+		default:
+			buf.WriteByte(0xdb)
+			binary.Write(buf, binary.BigEndian, uint32(n))
+		}
+		buf.WriteString(v)
+
+	case []interface{}:
+		n := len(v)
+		switch {
+		case n < 16:
+			buf.WriteByte(0x90 | byte(n))
+		case n <= math.MaxUint16:
+			buf.WriteByte(0xdc)
+			binary.Write(buf, binary.BigEndian, uint16(n))
+		default:
+			buf.WriteByte(0xdd)
+			binary.Write(buf, binary.BigEndian, uint32(n))
+		}
+		for _, e := range v {
+			packValue(buf, e)
+		}
+
+	case map[string]interface{}:
+		packMap(buf, v, nil)
+	}
+}
+
+// packMap will write m encoded with MessagePack to buf, with the keys
+// in first at the start and the rest sorted, like in the JSON output.
+func packMap(buf *bytes.Buffer, m map[string]interface{}, first []string) {
+	n := len(m)
+	switch {
+	case n < 16:
+		buf.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xde)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdf)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+
+	keys := make([]string, 0, n)
+	for k := range m {
+		keys = append(keys, k)
+	}
+	orderKeys(keys, first)
+	for _, k := range keys {
+		packValue(buf, k)
+		packValue(buf, m[k])
+	}
+}
+
+// packInt will write i to buf using the smallest
+// MessagePack integer encoding.
+func packInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i < 128:
+		buf.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		buf.Write([]byte{0xd0, byte(i)})
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		binary.Write(buf, binary.BigEndian, int16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		binary.Write(buf, binary.BigEndian, i)
+	}
+}
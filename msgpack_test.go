@@ -0,0 +1,194 @@
+package llogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+// unpack is a minimal MessagePack decoder for the types
+// produced by packValue, used to verify the encoding.
+func unpack(t *testing.T, r *bytes.Reader) interface{} {
+	b, err := r.ReadByte()
+	if err != nil {
+		t.Fatalf("Couldn't read MessagePack byte. Error %s", err.Error())
+	}
+
+	str := func(n int) string {
+		s := make([]byte, n)
+		r.Read(s)
+		return string(s)
+	}
+	mp := func(n int) map[string]interface{} {
+		m := map[string]interface{}{}
+		for i := 0; i < n; i++ {
+			k, _ := unpack(t, r).(string)
+			m[k] = unpack(t, r)
+		}
+		return m
+	}
+	arr := func(n int) []interface{} {
+		a := []interface{}{}
+		for i := 0; i < n; i++ {
+			a = append(a, unpack(t, r))
+		}
+		return a
+	}
+
+	switch {
+	case b < 0x80:
+		return int64(b)
+	case b >= 0xe0:
+		return int64(int8(b))
+	case b&0xf0 == 0x80:
+		return mp(int(b & 0x0f))
+	case b&0xf0 == 0x90:
+		return arr(int(b & 0x0f))
+	case b&0xe0 == 0xa0:
+		return str(int(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil
+	case 0xc2:
+		return false
+	case 0xc3:
+		return true
+	case 0xcb:
+		var u uint64
+		binary.Read(r, binary.BigEndian, &u)
+		return math.Float64frombits(u)
+	case 0xcf:
+		var u uint64
+		binary.Read(r, binary.BigEndian, &u)
+		return u
+	case 0xd0:
+		var i int8
+		binary.Read(r, binary.BigEndian, &i)
+		return int64(i)
+	case 0xd1:
+		var i int16
+		binary.Read(r, binary.BigEndian, &i)
+		return int64(i)
+	case 0xd2:
+		var i int32
+		binary.Read(r, binary.BigEndian, &i)
+		return int64(i)
+	case 0xd3:
+		var i int64
+		binary.Read(r, binary.BigEndian, &i)
+		return i
+	case 0xd9:
+		n, _ := r.ReadByte()
+		return str(int(n))
+	case 0xda:
+		var n uint16
+		binary.Read(r, binary.BigEndian, &n)
+		return str(int(n))
+	case 0xde:
+		var n uint16
+		binary.Read(r, binary.BigEndian, &n)
+		return mp(int(n))
+	}
+
+	t.Fatalf("Unexpected MessagePack byte 0x%x", b)
+	return nil
+}
+
+// TestMsgpack will test that messages are MessagePack encoded
+// when llogger-format is msgpack.
+func TestMsgpack(t *testing.T) {
+	client := Create(nil, Input{"llogger-format": "msgpack", "llogger-tf": "UnixNano", "service": "llogger-test"})
+
+	out := captureRaw(t, func() {
+		client.Print(Input{
+			"message": "Testmessage1",
+			"int":     -1337,
+			"float":   13.37,
+			"bool":    true,
+			"nil":     nil,
+			"nested":  map[string]interface{}{"list": []int{1, 2, 300}},
+			"long":    strings.Repeat("a", 40),
+		})
+	})
+
+	r := bytes.NewReader([]byte(out))
+	msg, ok := unpack(t, r).(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected MessagePack map but got %v", msg)
+	}
+	if r.Len() != 0 {
+		t.Fatalf("Expected no trailing data after MessagePack message but got %d bytes", r.Len())
+	}
+
+	nested, _ := msg["nested"].(map[string]interface{})
+	list, _ := nested["list"].([]interface{})
+	res, _ := msg["resource"].(map[string]interface{})
+
+	switch {
+	case msg["message"] != "Testmessage1":
+		t.Fatalf("Expected message to be Testmessage1 but got %v", msg["message"])
+
+	case msg["service"] != "llogger-test":
+		t.Fatalf("Expected service to be llogger-test but got %v", msg["service"])
+
+	case msg["int"] != int64(-1337):
+		t.Fatalf("Expected int to be -1337 but got %v", msg["int"])
+
+	case msg["float"] != 13.37:
+		t.Fatalf("Expected float to be 13.37 but got %v", msg["float"])
+
+	case msg["bool"] != true:
+		t.Fatalf("Expected bool to be true but got %v", msg["bool"])
+
+	case msg["nil"] != nil:
+		t.Fatalf("Expected nil to be nil but got %v", msg["nil"])
+
+	case msg["long"] != strings.Repeat("a", 40):
+		t.Fatalf("Expected long to be 40 a's but got %v", msg["long"])
+
+	case len(list) != 3 || list[0] != int64(1) || list[2] != int64(300):
+		t.Fatalf("Expected nested list to be [1 2 300] but got %v", nested["list"])
+
+	case !strings.HasSuffix(res["file"].(string), "msgpack_test.go"):
+		t.Fatalf("Expected resource file to be msgpack_test.go but got %v", res["file"])
+
+	case res["row"] == nil || res["function"] == nil:
+		t.Fatalf("Expected resource to have row and function but got %v", res)
+	}
+
+	if tm, ok := msg["time"].(int64); !ok || tm <= 0 {
+		t.Fatalf("Expected time to be UnixNano integer but got %v", msg["time"])
+	}
+}
+
+// TestMsgpackOrderAndUint will test that llogger-keyorder is used for
+// the top level keys and that integers above the int64 range are
+// encoded as uint64 without losing precision.
+func TestMsgpackOrderAndUint(t *testing.T) {
+	client := Create(nil, Input{"llogger-format": "msgpack", "llogger-keyorder": "message,loglevel"})
+
+	out := captureRaw(t, func() {
+		client.Print(Input{"loglevel": "info", "message": "Testmessage1", "big": uint64(math.MaxUint64)})
+	})
+
+	r := bytes.NewReader([]byte(out))
+	r.ReadByte()
+	first := unpack(t, r)
+	unpack(t, r)
+	second := unpack(t, r)
+
+	r = bytes.NewReader([]byte(out))
+	msg, _ := unpack(t, r).(map[string]interface{})
+
+	switch {
+	case first != "message" || second != "loglevel":
+		t.Fatalf("Expected message and loglevel to be the first keys but got %v and %v", first, second)
+
+	case msg["big"] != uint64(math.MaxUint64):
+		t.Fatalf("Expected big to be %d but got %v", uint64(math.MaxUint64), msg["big"])
+	}
+}
@@ -0,0 +1,55 @@
+package llogger
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// progressStep is the number of percent between progress messages.
+const progressStep = 10
+
+// Progress takes the total number of items of a long loop and returns
+// a function to call with the number of items done, current, and the
+// Input for the message. The message Progress is printed with log level
+// info, the current, total and percent fields and the estimated seconds
+// left in the eta field, based on the rate so far. To not flood the log
+// a message is only printed each time another 10 percent is done. With
+// a context the duration and timeLeft fields are included as usual, so
+// eta can be compared with the time left. The caller of the returned
+// function is used as resource.
+// Returns func(current int, inp Input).
+func (l *Client) Progress(total int) func(current int, inp Input) {
+	l = l.orDefault()
+	start := time.Now()
+	last := -1
+
+	var mu sync.Mutex
+	return func(current int, inp Input) {
+		percent := 100.0
+		if total > 0 {
+			percent = math.Min(100, float64(current)*100/float64(total))
+		}
+
+		// Only print when another step is done, or when done.
+		mu.Lock()
+		step := int(percent) / progressStep
+		if step <= last {
+			mu.Unlock()
+			return
+		}
+		last = step
+		mu.Unlock()
+
+		data := l.leveled("info", "Progress", inp)
+		data["current"] = current
+		data["total"] = total
+		data["percent"] = math.Round(percent*10) / 10
+		if current > 0 {
+			left := math.Max(0, float64(total-current))
+			data["eta"] = time.Since(start).Seconds() / float64(current) * left
+		}
+
+		l.print(call{skip: 2}, data)
+	}
+}
@@ -0,0 +1,46 @@
+package llogger
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestProgress will test that progress messages are only printed
+// for every 10 percent with increasing percentages.
+func TestProgress(t *testing.T) {
+	client := Create(nil, nil)
+
+	strs := capture(t, func() {
+		progress := client.Progress(200)
+		for i := 0; i <= 200; i++ {
+			progress(i, Input{"batch": "1337"})
+		}
+		progress(200, nil)
+	})
+
+	if len(strs) != 11 {
+		t.Fatalf("Expected 11 progress messages but got %d", len(strs))
+	}
+
+	for i, str := range strs {
+		msg := decode(t, str)
+		res, _ := msg["resource"].(map[string]interface{})
+
+		switch {
+		case msg["message"] != "Progress" || msg["loglevel"] != "info" || msg["batch"] != "1337":
+			t.Fatalf("Expected progress message with log level info but got %s", str)
+
+		case msg["percent"] != float64(i*10) || msg["current"] != float64(i*20) || msg["total"] != float64(200):
+			t.Fatalf("Expected percent %d in message %d but got %s", i*10, i, str)
+
+		case i == 0 && msg["eta"] != nil:
+			t.Fatalf("Expected no eta without any items done but got %s", str)
+
+		case i == 10 && msg["eta"] != float64(0):
+			t.Fatalf("Expected eta 0 when done but got %s", str)
+
+		case !strings.HasSuffix(res["file"].(string), "progress_test.go"):
+			t.Fatalf("Expected the caller of progress as resource but got %s", str)
+		}
+	}
+}
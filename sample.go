@@ -0,0 +1,114 @@
+package llogger
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sampler decides which messages to keep when sampling.
+// It's safe for concurrent use.
+type sampler struct {
+	mu   sync.Mutex
+	rate float64
+	rnd  *rand.Rand
+	key  string // Field whose value the sampling decision is based on
+
+	// Log levels that are always kept.
+	bypass map[string]bool
+}
+
+// setSampling will set the sample rate and seed from l.data. The rate
+// is the fraction of messages to keep between 0 and 1 and defaults to
+// 1, keeping all messages. The seed defaults to the current time.
+func (l *Client) setSampling() {
+	rate, ok := l.configFloat("llogger-sample")
+	seed, hasSeed := l.configInt("llogger-seed")
+	if !hasSeed {
+		seed = time.Now().UnixNano()
+	}
+
+	switch {
+	case !ok || rate >= 1:
+		rate = 1
+
+	case rate < 0:
+		rate = 0
+	}
+
+	l.sampler = &sampler{rate: rate, rnd: rand.New(rand.NewSource(seed))}
+
+	// Log levels that bypass sampling, by default the log levels
+	// set with llogger-wm and llogger-cm and "critical".
+	levels := []string{l.wm, l.cm, "critical"}
+	if bypass, ok := l.data["llogger-nosample"]; ok {
+		switch bypass := bypass.(type) {
+		case []string:
+			levels = bypass
+
+		case string:
+			levels = strings.Split(bypass, ",")
+		}
+		delete(l.data, "llogger-nosample")
+	}
+	l.sampler.bypass = map[string]bool{}
+	for _, level := range levels {
+		if level = strings.TrimSpace(level); level != "" {
+			l.sampler.bypass[level] = true
+		}
+	}
+
+	// Field to base the sampling decision on instead of randomness.
+	if key, ok := l.configString("llogger-samplekey"); ok {
+		l.sampler.key = key
+	}
+
+	// Field with the sampling decision.
+	if on, _ := l.configBool("llogger-sampled"); on {
+		l.sfn = "sampled"
+	}
+	if sfn, ok := l.configString("llogger-sfn"); ok && l.sfn != "" {
+		l.sfn = sfn
+	}
+}
+
+// sample will return if the message out should be kept. Messages with
+// a log level that bypasses sampling are always kept. If the sampler has
+// a key and out has a value for it the decision is based on a hash of
+// the value, so that all messages with the same value are either kept or
+// dropped.
+// Returns bool.
+func (l *Client) sample(out output) bool {
+	if l.sampler == nil || l.sampler.rate >= 1 {
+		return true
+	}
+	if level, ok := out[l.llfn].(string); ok && l.sampler.bypass[level] {
+		return true
+	}
+
+	if v, ok := out[l.sampler.key]; ok && l.sampler.key != "" {
+		h := fnv.New64a()
+		fmt.Fprint(h, v)
+		return float64(mix(h.Sum64())>>11)/(1<<53) < l.sampler.rate
+	}
+
+	l.sampler.mu.Lock()
+	defer l.sampler.mu.Unlock()
+	return l.sampler.rnd.Float64() < l.sampler.rate
+}
+
+// mix will mix the bits of the hash h, since FNV spreads short values
+// such as IDs poorly over the high bits.
+// Returns uint64.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+
+	return h
+}
@@ -0,0 +1,128 @@
+package llogger
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestSampled will test that messages are sampled with a fixed
+// seed and that the sampled field matches the outcome.
+func TestSampled(t *testing.T) {
+	client := Create(nil, Input{"llogger-sample": 0.5, "llogger-seed": 1337, "llogger-sampled": true})
+
+	// Calculate the expected outcome with the same seed.
+	rnd := rand.New(rand.NewSource(1337))
+	expected := []int{}
+	for i := 0; i < 100; i++ {
+		if rnd.Float64() < 0.5 {
+			expected = append(expected, i)
+		}
+	}
+
+	strs := capture(t, func() {
+		for i := 0; i < 100; i++ {
+			client.Info("Testmessage", Input{"i": i})
+		}
+		client.Error("Testmessage error", nil)
+	})
+
+	if len(strs) != len(expected)+1 {
+		t.Fatalf("Expected %d lines from stdout but got %d", len(expected)+1, len(strs))
+	}
+
+	for i, str := range strs[:len(expected)] {
+		msg := decode(t, str)
+		switch {
+		case msg["i"] != float64(expected[i]):
+			t.Fatalf("Expected line %d to be message %d but got %v", i, expected[i], msg["i"])
+
+		case msg["sampled"] != true:
+			t.Fatalf("Expected sampled to be true for kept message but got %v", msg["sampled"])
+		}
+	}
+
+	// Check that errors are always kept.
+	if msg := decode(t, strs[len(strs)-1]); msg["message"] != "Testmessage error" || msg["sampled"] != true {
+		t.Fatalf("Expected error to always be kept with sampled true but got %s", strs[len(strs)-1])
+	}
+}
+
+// TestNotSampled will test that all messages are kept
+// when sampling isn't enabled.
+func TestNotSampled(t *testing.T) {
+	client := Create(nil, nil)
+
+	strs := capture(t, func() {
+		for i := 0; i < 10; i++ {
+			client.Info("Testmessage", nil)
+		}
+	})
+
+	if len(strs) != 10 {
+		t.Fatalf("Expected 10 lines from stdout but got %d", len(strs))
+	}
+	if msg := decode(t, strs[0]); msg["sampled"] != nil {
+		t.Fatalf("Expected no sampled field when not enabled but got %v", msg["sampled"])
+	}
+}
+
+// TestSampleKey will test that the sampling decision is the same
+// for all messages with the same value of the sample key.
+func TestSampleKey(t *testing.T) {
+	client := Create(nil, Input{"llogger-sample": 0.5, "llogger-samplekey": "customerId"})
+
+	strs := capture(t, func() {
+		for i := 0; i < 20; i++ {
+			for c := 0; c < 10; c++ {
+				client.Info("Testmessage", Input{"customerId": c, "i": i})
+			}
+		}
+		client.Error("Testmessage error", Input{"customerId": -1})
+	})
+
+	counts := map[float64]int{}
+	for _, str := range strs[:len(strs)-1] {
+		counts[decode(t, str)["customerId"].(float64)]++
+	}
+
+	for c, n := range counts {
+		if n != 20 {
+			t.Fatalf("Expected all or no messages for customer %v to be kept but got %d of 20", c, n)
+		}
+	}
+
+	switch {
+	case len(counts) == 0 || len(counts) == 10:
+		t.Fatalf("Expected some but not all customers to be kept but got %d", len(counts))
+
+	case decode(t, strs[len(strs)-1])["message"] != "Testmessage error":
+		t.Fatalf("Expected error to always be kept but got %s", strs[len(strs)-1])
+	}
+}
+
+// TestSampleBypass will test that messages with a log level that
+// bypasses sampling are always kept.
+func TestSampleBypass(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-sample": 0})
+	client2 := Create(nil, Input{"llogger-sample": 0, "llogger-nosample": "audit, error"})
+
+	strs := capture(t, func() {
+		client1.Info("Testmessage1", nil)
+		client1.Warn("Testmessage2", nil)
+		client1.Error("Testmessage3", nil)
+		client2.Info("Testmessage4", nil)
+		client2.Warn("Testmessage5", nil)
+		client2.Log("audit", "Testmessage6", nil)
+		client2.Error("Testmessage7", nil)
+	})
+
+	if len(strs) != 4 {
+		t.Fatalf("Expected 4 messages but got %q", strs)
+	}
+
+	for i, message := range []string{"Testmessage2", "Testmessage3", "Testmessage6", "Testmessage7"} {
+		if msg := decode(t, strs[i]); msg["message"] != message {
+			t.Fatalf("Expected %s to bypass sampling but got %s", message, strs[i])
+		}
+	}
+}
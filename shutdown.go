@@ -0,0 +1,57 @@
+package llogger
+
+import (
+	"context"
+)
+
+// setShutdownMonitor will read llogger-shutdown and return a function
+// that starts the shutdown monitor if it's set to a context, for example
+// one that is cancelled on SIGTERM. When the context is done a message
+// with the warning log level is printed, with the error of the context
+// in the reason field, and the buffered messages are written to the
+// writer. The returned function does nothing if no context is set.
+// Returns func().
+func (l *Client) setShutdownMonitor() func() {
+	v, ok := l.data["llogger-shutdown"]
+	if !ok {
+		return func() {}
+	}
+	delete(l.data, "llogger-shutdown")
+
+	ctx, ok := v.(context.Context)
+	if !ok || ctx == nil {
+		return func() {}
+	}
+
+	return func() { l.startShutdownMonitor(ctx) }
+}
+
+// startShutdownMonitor will start the shutdown monitor
+// for ctx.
+func (l *Client) startShutdownMonitor(ctx context.Context) {
+	m := &monitor{stop: make(chan struct{}), done: make(chan struct{})}
+	l.shutdownMonitor = m
+
+	go func() {
+		defer close(m.done)
+
+		select {
+		case <-ctx.Done():
+			l.print(call{skip: 1}, Input{l.llfn: l.wm, l.mfn: "Shutting down", "reason": ctx.Err().Error()})
+			l.Sync()
+
+		case <-m.stop:
+		}
+	}()
+}
+
+// stopShutdownMonitor will stop the shutdown monitor of l
+// if it's running and wait for it to exit.
+func (l *Client) stopShutdownMonitor() {
+	m := l.shutdownMonitor
+	if m == nil {
+		return
+	}
+
+	m.halt()
+}
@@ -0,0 +1,91 @@
+package llogger
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestShutdownMonitor will test that a shutdown message is printed
+// and the buffered messages are flushed when the context set with
+// llogger-shutdown is cancelled.
+func TestShutdownMonitor(t *testing.T) {
+	buf := &syncBuffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := Create(nil, Input{"llogger-writer": buf, "llogger-buffered": true, "llogger-shutdown": ctx})
+	defer client.Close()
+
+	client.Print(Input{"message": "Testmessage1"})
+	if buf.String() != "" {
+		t.Fatalf("Expected nothing to be written before the shutdown but got %s", buf.String())
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "Shutting down") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	strs := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(strs) != 2 {
+		t.Fatalf("Expected the buffered message and the shutdown message to be flushed but got %q", strs)
+	}
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+
+	switch {
+	case msg1["message"] != "Testmessage1":
+		t.Fatalf("Expected Testmessage1 to be flushed first but got %s", strs[0])
+
+	case msg2["loglevel"] != "warning" || msg2["message"] != "Shutting down" || msg2["reason"] != "context canceled":
+		t.Fatalf("Expected a warning with the shutdown reason in msg2 but got %s", strs[1])
+
+	case msg2["llogger-shutdown"] != nil:
+		t.Fatalf("Expected llogger-shutdown to not be printed but got %s", strs[1])
+	}
+}
+
+// TestShutdownMonitorClose will test that Close stops the shutdown
+// monitor so nothing is printed when the context is cancelled after.
+func TestShutdownMonitorClose(t *testing.T) {
+	buf := &syncBuffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	client := Create(nil, Input{"llogger-writer": buf, "llogger-shutdown": ctx})
+	client.Close()
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+
+	if buf.String() != "" {
+		t.Fatalf("Expected nothing to be printed after Close but got %s", buf.String())
+	}
+}
+
+// TestMonitorsConcurrentClose will test that the memory and shutdown
+// monitors can be stopped by several clients closing at once.
+func TestMonitorsConcurrentClose(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := Create(nil, Input{
+		"llogger-writer":      &syncBuffer{},
+		"llogger-shutdown":    ctx,
+		"llogger-memwarn":     90,
+		"llogger-memlimit":    100000,
+		"llogger-meminterval": time.Hour,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client.WithFields(Input{"i": i}).Close()
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,222 @@
+//go:build go1.21
+// +build go1.21
+
+package llogger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// slogHandler is a slog.Handler that prints records with client.
+type slogHandler struct {
+	client *Client
+	opts   slog.HandlerOptions
+	attrs  []groupedAttr
+	groups []string
+}
+
+// groupedAttr is an attribute added with WithAttrs together
+// with the groups that were open when it was added.
+type groupedAttr struct {
+	groups []string
+	attr   slog.Attr
+}
+
+// SlogHandler takes opts and returns a slog.Handler that prints all records
+// with l. The slog levels debug and info are printed as "debug" and "info"
+// while warn and error are printed with the warning and critical log levels
+// set by llogger-wm and llogger-cm. Other levels are printed as their lower
+// case slog names, for example "info+2".
+// The built in time, level and message attributes are mapped to the time,
+// log level and message fields of l. Just like slog.HandlerOptions all
+// attributes are passed through opts.ReplaceAttr, if set, before they are
+// mapped so they can be renamed or dropped. The resource field is always set
+// so opts.AddSource is ignored. opts can be nil.
+// Returns slog.Handler.
+func (l *Client) SlogHandler(opts *slog.HandlerOptions) slog.Handler {
+	l = l.orDefault()
+	h := &slogHandler{client: l}
+	if opts != nil {
+		h.opts = *opts
+	}
+
+	return h
+}
+
+// Enabled reports whether level is at least the level set in
+// the handler options. Defaults to slog.LevelInfo.
+// Returns bool.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	min := slog.LevelInfo
+	if h.opts.Level != nil {
+		min = h.opts.Level.Level()
+	}
+
+	return level >= min
+}
+
+// Handle prints r with the client. The caller of the slog
+// function is used as resource and ctx is used for the deadline.
+// Returns error.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	l := h.client
+	c := call{skip: 2, pc: r.PC, ctx: ctx}
+	inp := Input{}
+
+	// The time field is always set by the client so it must be
+	// omitted if the time attribute is dropped or renamed.
+	if r.Time.IsZero() {
+		c.omit = append(c.omit, l.tfn)
+	} else {
+		a := h.builtin(slog.Time(slog.TimeKey, r.Time), slog.TimeKey, l.tfn)
+		if a.Key != l.tfn {
+			c.omit = append(c.omit, l.tfn)
+		}
+		if a.Key != "" {
+			if a.Value.Kind() == slog.KindTime {
+				inp[a.Key] = l.formatTime(a.Value.Time())
+			} else {
+				inp[a.Key] = slogValue(a.Value)
+			}
+		}
+	}
+
+	if a := h.builtin(slog.Any(slog.LevelKey, r.Level), slog.LevelKey, l.llfn); a.Key != "" {
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			inp[a.Key] = h.levelName(level)
+		} else {
+			inp[a.Key] = slogValue(a.Value)
+		}
+	}
+
+	if a := h.builtin(slog.String(slog.MessageKey, r.Message), slog.MessageKey, l.mfn); a.Key != "" {
+		inp[a.Key] = slogValue(a.Value)
+	}
+
+	// Add the attributes from WithAttrs and then the record.
+	for _, ga := range h.attrs {
+		h.addAttr(inp, ga.groups, ga.attr)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		h.addAttr(inp, h.groups, a)
+		return true
+	})
+
+	l.print(c, inp)
+	return nil
+}
+
+// WithAttrs returns a new handler that adds attrs to all records.
+// Returns slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nh := *h
+	nh.attrs = make([]groupedAttr, len(h.attrs), len(h.attrs)+len(attrs))
+	copy(nh.attrs, h.attrs)
+	for _, a := range attrs {
+		nh.attrs = append(nh.attrs, groupedAttr{groups: h.groups, attr: a})
+	}
+
+	return &nh
+}
+
+// WithGroup returns a new handler that nests all following
+// attributes under name.
+// Returns slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	nh := *h
+	nh.groups = make([]string, len(h.groups), len(h.groups)+1)
+	copy(nh.groups, h.groups)
+	nh.groups = append(nh.groups, name)
+
+	return &nh
+}
+
+// builtin will pass the built in attribute a through ReplaceAttr
+// and map the key to field if it's still named key.
+// Returns slog.Attr.
+func (h *slogHandler) builtin(a slog.Attr, key string, field string) slog.Attr {
+	if h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(nil, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Key == key {
+		a.Key = field
+	}
+
+	return a
+}
+
+// addAttr will add a to inp nested under groups. a is passed
+// through ReplaceAttr and empty attributes and groups are dropped.
+func (h *slogHandler) addAttr(inp Input, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup && h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range attrs {
+			h.addAttr(inp, groups, ga)
+		}
+		return
+	}
+
+	if a.Key == "" {
+		return
+	}
+
+	// Find or create the map for the groups.
+	target := map[string]interface{}(inp)
+	for _, g := range groups {
+		m, ok := target[g].(map[string]interface{})
+		if !ok {
+			m = map[string]interface{}{}
+			target[g] = m
+		}
+		target = m
+	}
+	target[a.Key] = slogValue(a.Value)
+}
+
+// levelName will return the llogger log level for level.
+// Returns string.
+func (h *slogHandler) levelName(level slog.Level) string {
+	switch level {
+	case slog.LevelDebug:
+		return "debug"
+
+	case slog.LevelInfo:
+		return "info"
+
+	case slog.LevelWarn:
+		return h.client.wm
+
+	case slog.LevelError:
+		return h.client.cm
+
+	default:
+		return strings.ToLower(level.String())
+	}
+}
+
+// slogValue will return v as a value that can be JSON marshaled.
+// Errors are returned as their message.
+// Returns interface{}.
+func slogValue(v slog.Value) interface{} {
+	if err, ok := v.Any().(error); ok {
+		return err.Error()
+	}
+
+	return v.Any()
+}
@@ -0,0 +1,109 @@
+//go:build go1.21
+// +build go1.21
+
+package llogger
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestSlogHandler will test printing slog records with
+// attributes and groups through SlogHandler.
+func TestSlogHandler(t *testing.T) {
+	client := Create(nil, Input{"service": "llogger-test", "llogger-wm": "custom-warning"})
+	logger := slog.New(client.SlogHandler(nil)).With("requestId", "1337").WithGroup("db")
+
+	strs := capture(t, func() {
+		logger.Warn("Testmessage1", "table", "items", slog.Group("query", "rows", 42), "err", errors.New("flux capacitor"))
+		logger.Debug("Testmessage2")
+	})
+
+	if len(strs) != 1 {
+		t.Fatalf("Expected 1 line from stdout since debug is disabled but got %d", len(strs))
+	}
+
+	msg := decode(t, strs[0])
+	db, _ := msg["db"].(map[string]interface{})
+	query, _ := db["query"].(map[string]interface{})
+	res, _ := msg["resource"].(map[string]interface{})
+
+	switch {
+	case msg["loglevel"] != "custom-warning":
+		t.Fatalf("Expected loglevel to be custom-warning but got %v", msg["loglevel"])
+
+	case msg["message"] != "Testmessage1":
+		t.Fatalf("Expected message to be Testmessage1 but got %v", msg["message"])
+
+	case msg["time"] == nil:
+		t.Fatalf("Expected time to be set")
+
+	case msg["service"] != "llogger-test":
+		t.Fatalf("Expected service to be llogger-test but got %v", msg["service"])
+
+	case msg["requestId"] != "1337":
+		t.Fatalf("Expected requestId to be 1337 outside of group but got %v", msg["requestId"])
+
+	case db["table"] != "items":
+		t.Fatalf("Expected db.table to be items but got %v", db["table"])
+
+	case query["rows"] != float64(42):
+		t.Fatalf("Expected db.query.rows to be 42 but got %v", query["rows"])
+
+	case db["err"] != "flux capacitor":
+		t.Fatalf("Expected db.err to be flux capacitor but got %v", db["err"])
+
+	case !strings.HasSuffix(res["file"].(string), "slog_test.go"):
+		t.Fatalf("Expected resource file to be slog_test.go but got %v", res["file"])
+	}
+}
+
+// TestSlogReplaceAttr will test that ReplaceAttr can drop and
+// rename the built in attributes.
+func TestSlogReplaceAttr(t *testing.T) {
+	client := Create(nil, nil)
+	logger := slog.New(client.SlogHandler(&slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch {
+			case len(groups) == 0 && a.Key == slog.TimeKey:
+				return slog.Attr{}
+
+			case len(groups) == 0 && a.Key == slog.LevelKey:
+				return slog.String("severity", strings.ToUpper(a.Value.String()))
+
+			case a.Key == "secret":
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	strs := capture(t, func() {
+		logger.Debug("Testmessage1", "secret", "hunter2", "kept", true)
+	})
+
+	msg := decode(t, strs[0])
+
+	switch {
+	case msg["time"] != nil:
+		t.Fatalf("Expected time to be dropped but got %v", msg["time"])
+
+	case msg["loglevel"] != nil:
+		t.Fatalf("Expected loglevel to be renamed but got %v", msg["loglevel"])
+
+	case msg["severity"] != "DEBUG":
+		t.Fatalf("Expected severity to be DEBUG but got %v", msg["severity"])
+
+	case msg["message"] != "Testmessage1":
+		t.Fatalf("Expected message to be Testmessage1 but got %v", msg["message"])
+
+	case msg["secret"] != nil:
+		t.Fatalf("Expected secret to be dropped but got %v", msg["secret"])
+
+	case msg["kept"] != true:
+		t.Fatalf("Expected kept to be true but got %v", msg["kept"])
+	}
+}
@@ -0,0 +1,106 @@
+package llogger
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// cloudWatchMaxEvent is the max size in bytes of a CloudWatch Logs
+// event, 256 KB minus the 26 bytes of overhead per event.
+const cloudWatchMaxEvent = 256*1024 - 26
+
+// setSplit will set the max size of a line before it's split into
+// parts from llogger-split. It can be true for the max size of a
+// CloudWatch Logs event or a size in bytes.
+func (l *Client) setSplit() {
+	v, ok := l.data["llogger-split"]
+	if !ok {
+		return
+	}
+	delete(l.data, "llogger-split")
+
+	switch v := v.(type) {
+	case bool:
+		if v {
+			l.splitSize = cloudWatchMaxEvent
+		}
+
+	case int:
+		l.splitSize = v
+
+	case int64:
+		l.splitSize = int(v)
+
+	case string:
+		if on, err := strconv.ParseBool(v); err == nil {
+			if on {
+				l.splitSize = cloudWatchMaxEvent
+			}
+		} else if size, err := strconv.Atoi(v); err == nil {
+			l.splitSize = size
+		}
+	}
+
+	if l.splitSize < 0 {
+		l.splitSize = 0
+	}
+}
+
+// splitParts will split the JSON message raw into parts whose JSON is at
+// most max bytes. Each part has the time and log level of out, a shared
+// random splitId, the 1-based part number in part, the number of parts
+// in total and a piece of raw in splitData. Joining the splitData of all
+// parts in order gives raw. ok is false if max is too small to fit a
+// part with any data.
+// Returns [][]byte and bool.
+func (l *Client) splitParts(out output, raw []byte, max int) ([][]byte, bool) {
+	part := output{"splitId": newEventID(), "splitData": ""}
+	for _, k := range []string{l.tfn, l.llfn} {
+		if v, ok := out[k]; ok {
+			part[k] = v
+		}
+	}
+
+	// The size of a part without data, with room for the
+	// largest possible part numbers.
+	part["part"], part["total"] = len(raw), len(raw)
+	empty, err := l.marshal(part)
+	if err != nil {
+		return nil, false
+	}
+	room := max - len(empty)
+
+	// Split raw between runes so that each piece fits in room
+	// when escaped as a JSON string.
+	s := string(raw)
+	pieces := []string{}
+	start, size := 0, 0
+	buf := make([]byte, 0, 16)
+	for i := 0; i < len(s); {
+		_, n := utf8.DecodeRuneInString(s[i:])
+		buf = appendJSONString(buf[:0], s[i:i+n])
+		escaped := len(buf) - 2
+		if escaped > room {
+			return nil, false
+		}
+		if size+escaped > room {
+			pieces = append(pieces, s[start:i])
+			start, size = i, 0
+		}
+		size += escaped
+		i += n
+	}
+	pieces = append(pieces, s[start:])
+
+	parts := make([][]byte, 0, len(pieces))
+	for i, piece := range pieces {
+		part["part"], part["total"], part["splitData"] = i+1, len(pieces), piece
+		b, err := l.marshal(part)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, b)
+	}
+
+	return parts, true
+}
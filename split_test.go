@@ -0,0 +1,58 @@
+package llogger
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSplit will test that lines larger than llogger-split are split
+// into parts that can be joined back into the message.
+func TestSplit(t *testing.T) {
+	client := Create(nil, Input{"llogger-split": 1000, "llogger-prefix": "PRE "})
+	large := strings.Repeat(`a"<`, 600)
+
+	strs := capture(t, func() {
+		client.Info("Testmessage1", Input{"large": large})
+		client.Info("Testmessage2", nil)
+	})
+
+	// The escaped JSON of large is about 6000 bytes, so it
+	// needs at least 6 parts of at most 1000 bytes.
+	if len(strs) < 7 {
+		t.Fatalf("Expected the first message to be split into at least 6 parts but got %d lines", len(strs))
+	}
+
+	var joined strings.Builder
+	parts := strs[:len(strs)-1]
+	first := decode(t, strings.TrimPrefix(parts[0], "PRE "))
+	for i, str := range parts {
+		if len(str)+1 > 1000 || !strings.HasPrefix(str, "PRE ") {
+			t.Fatalf("Expected part %d to be at most 1000 bytes with the prefix but got %d bytes", i+1, len(str)+1)
+		}
+		part := decode(t, strings.TrimPrefix(str, "PRE "))
+
+		switch {
+		case part["splitId"] == nil || part["splitId"] != first["splitId"]:
+			t.Fatalf("Expected all parts to have the same splitId but got %s", str)
+
+		case part["part"] != float64(i+1) || part["total"] != float64(len(parts)):
+			t.Fatalf("Expected part %d of %d but got %s", i+1, len(parts), str)
+
+		case part["loglevel"] != "info" || part["time"] == nil:
+			t.Fatalf("Expected the log level and time of the message in the part but got %s", str)
+		}
+		data, _ := part["splitData"].(string)
+		joined.WriteString(data)
+	}
+
+	msg1 := decode(t, joined.String())
+	msg2 := decode(t, strings.TrimPrefix(strs[len(strs)-1], "PRE "))
+
+	switch {
+	case msg1["message"] != "Testmessage1" || msg1["large"] != large:
+		t.Fatalf("Expected the joined parts to be the message but got %s", joined.String())
+
+	case msg2["message"] != "Testmessage2" || msg2["splitId"] != nil:
+		t.Fatalf("Expected msg2 to not be split but got %s", strs[len(strs)-1])
+	}
+}
@@ -0,0 +1,135 @@
+package llogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// eventFieldMax is the max size in bytes of the JSON of a field of the
+// event summary printed by Start. Larger values are replaced with
+// eventFieldPlaceholder so that large events are never printed whole.
+// The values of the keys in llogger-evredact are replaced with
+// eventRedactPlaceholder.
+const (
+	eventFieldMax          = 1024
+	eventFieldPlaceholder  = "<truncated size>"
+	eventRedactPlaceholder = "<redacted>"
+)
+
+// Start prints a message with log level info when an invocation starts,
+// with a summary of event in the event field. The summary has the Go
+// type of event in type and the size of its JSON in bytes in size. The
+// top level fields of event listed in llogger-eventfields are added in
+// fields, with values larger than 1 KB of JSON replaced by a
+// placeholder. The values of the keys listed in llogger-evredact are
+// replaced with <redacted>, both for the fields and for keys nested in
+// them. No other part of event is printed.
+func (l *Client) Start(event interface{}) {
+	l = l.orDefault()
+	l.print(call{skip: 2}, l.leveled("info", "Invocation started", Input{"event": l.eventSummary(event)}))
+}
+
+// eventSummary will return the summary of event printed by Start.
+// Events that are []byte or json.RawMessage are used as JSON as is.
+// Returns map[string]interface{}.
+func (l *Client) eventSummary(event interface{}) map[string]interface{} {
+	summary := map[string]interface{}{"type": fmt.Sprintf("%T", event)}
+
+	var raw []byte
+	switch event := event.(type) {
+	case json.RawMessage:
+		raw = event
+
+	case []byte:
+		raw = event
+
+	default:
+		b, _, err := safeMarshal(event, nil)
+		if err != nil {
+			return summary
+		}
+		raw = b
+	}
+	summary["size"] = len(raw)
+
+	if len(l.eventFields) == 0 {
+		return summary
+	}
+
+	obj := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return summary
+	}
+
+	fields := map[string]interface{}{}
+	for _, k := range l.eventFields {
+		v, ok := obj[k]
+		if !ok {
+			continue
+		}
+		if l.eventRedact[k] {
+			fields[k] = eventRedactPlaceholder
+			continue
+		}
+		if len(l.eventRedact) > 0 {
+			v = l.redactEvent(v)
+		}
+		if len(v) > eventFieldMax {
+			fields[k] = eventFieldPlaceholder
+			continue
+		}
+		fields[k] = v
+	}
+	summary["fields"] = fields
+
+	return summary
+}
+
+// redactEvent will return the JSON raw with the values of the keys in
+// l.eventRedact replaced with eventRedactPlaceholder, at any depth.
+// raw is returned as is if nothing was redacted.
+// Returns json.RawMessage.
+func (l *Client) redactEvent(raw json.RawMessage) json.RawMessage {
+	var val interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&val); err != nil {
+		return raw
+	}
+
+	if !l.redactValue(val) {
+		return raw
+	}
+	redacted, err := json.Marshal(val)
+	if err != nil {
+		return raw
+	}
+
+	return redacted
+}
+
+// redactValue will replace the values of the keys in l.eventRedact in
+// the objects in v, and the objects and arrays nested in them.
+// Returns bool, true if anything was redacted.
+func (l *Client) redactValue(v interface{}) bool {
+	redacted := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if l.eventRedact[k] {
+				v[k] = eventRedactPlaceholder
+				redacted = true
+				continue
+			}
+			redacted = l.redactValue(e) || redacted
+		}
+
+	case []interface{}:
+		for _, e := range v {
+			redacted = l.redactValue(e) || redacted
+		}
+	}
+
+	return redacted
+}
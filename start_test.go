@@ -0,0 +1,80 @@
+package llogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// startEvent is an incoming event used to test Start.
+type startEvent struct {
+	OrderID string   `json:"orderId"`
+	Source  string   `json:"source"`
+	Items   []string `json:"items"`
+	Secret  string   `json:"secret"`
+}
+
+// TestStart will test that Start prints a summary of the event with
+// its type name, size and only the selected fields.
+func TestStart(t *testing.T) {
+	client1 := Create(nil, nil)
+	client2 := Create(nil, Input{"llogger-eventfields": "orderId, items, missing"})
+	event := startEvent{OrderID: "1337", Source: "web", Items: make([]string, 500), Secret: "hunter2"}
+	raw, _ := json.Marshal(event)
+
+	strs := capture(t, func() {
+		client1.Start(event)
+		client2.Start(&event)
+		client2.Start(json.RawMessage(`{"orderId":42}`))
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+	event1, _ := msg1["event"].(map[string]interface{})
+	event2, _ := msg2["event"].(map[string]interface{})
+	event3, _ := msg3["event"].(map[string]interface{})
+
+	switch {
+	case msg1["loglevel"] != "info" || msg1["message"] != "Invocation started":
+		t.Fatalf("Expected an info message about the invocation start in msg1 but got %s", strs[0])
+
+	case event1["type"] != "llogger.startEvent" || event1["size"] != float64(len(raw)) || event1["fields"] != nil:
+		t.Fatalf("Expected the type name and size but no fields in msg1 but got %s", strs[0])
+
+	case event2["type"] != "*llogger.startEvent":
+		t.Fatalf("Expected the type name of the pointer in msg2 but got %s", strs[1])
+
+	case !reflect.DeepEqual(event2["fields"], map[string]interface{}{"orderId": "1337", "items": "<truncated size>"}):
+		t.Fatalf("Expected only the selected fields with items truncated in msg2 but got %s", strs[1])
+
+	case strings.Contains(strs[1], "hunter2") || strings.Contains(strs[1], "web"):
+		t.Fatalf("Expected no fields that weren't selected in msg2 but got %s", strs[1])
+
+	case event3["type"] != fmt.Sprintf("%T", json.RawMessage{}) || !reflect.DeepEqual(event3["fields"], map[string]interface{}{"orderId": float64(42)}):
+		t.Fatalf("Expected the raw JSON to be used as the event in msg3 but got %s", strs[2])
+	}
+}
+
+// TestStartRedact will test that the keys in llogger-evredact are
+// redacted in the fields of the event summary, also when nested.
+func TestStartRedact(t *testing.T) {
+	client := Create(nil, Input{"llogger-eventfields": "orderId,secret,customer", "llogger-evredact": []string{"secret", "email"}})
+	event := json.RawMessage(`{"orderId":"1337","secret":"hunter2","customer":{"id":42,"contacts":[{"email":"a@example.com"}]}}`)
+
+	strs := capture(t, func() {
+		client.Start(event)
+	})
+	msg := decode(t, strs[0])
+	summary, _ := msg["event"].(map[string]interface{})
+	customer := map[string]interface{}{"id": float64(42), "contacts": []interface{}{map[string]interface{}{"email": "<redacted>"}}}
+
+	switch {
+	case !reflect.DeepEqual(summary["fields"], map[string]interface{}{"orderId": "1337", "secret": "<redacted>", "customer": customer}):
+		t.Fatalf("Expected secret and the nested email to be redacted but got %s", strs[0])
+
+	case strings.Contains(strs[0], "hunter2") || strings.Contains(strs[0], "a@example.com"):
+		t.Fatalf("Expected no redacted values in the message but got %s", strs[0])
+	}
+}
@@ -0,0 +1,34 @@
+package llogger
+
+import (
+	"bytes"
+	"io"
+)
+
+// stdLogWriter is an io.Writer that prints each write as a
+// message with log level level using client.
+type stdLogWriter struct {
+	client *Client
+	level  string
+}
+
+// StdLogWriter takes log level level and returns an io.Writer that can
+// be passed to log.New. Each write to the writer is printed as a message
+// with log level level and the written text as message. The trailing
+// newline added by log is removed. The caller of the log function
+// will be used as resource.
+// Returns io.Writer.
+func (l *Client) StdLogWriter(level string) io.Writer {
+	l = l.orDefault()
+	return &stdLogWriter{client: l, level: level}
+}
+
+// Write prints p as a message.
+// Returns int and error.
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	msg := string(bytes.TrimSuffix(p, []byte("\n")))
+
+	// Skip Write and the log.Logger output and print functions.
+	w.client.print(call{skip: 4}, Input{w.client.llfn: w.level, w.client.mfn: msg})
+	return len(p), nil
+}
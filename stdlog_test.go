@@ -0,0 +1,45 @@
+package llogger
+
+import (
+	"log"
+	"strings"
+	"testing"
+)
+
+// TestStdLogWriter will test printing with the standard library
+// log package through StdLogWriter.
+func TestStdLogWriter(t *testing.T) {
+	client := Create(nil, Input{"service": "llogger-test"})
+	logger := log.New(client.StdLogWriter("info"), "", 0)
+
+	strs := capture(t, func() {
+		logger.Printf("Testmessage%d", 1)
+		logger.Println("Testmessage2")
+	})
+
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 lines from stdout but got %d", len(strs))
+	}
+
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	res, _ := msg1["resource"].(map[string]interface{})
+
+	switch {
+	case msg1["loglevel"] != "info":
+		t.Fatalf("Expected loglevel to be info but got %v", msg1["loglevel"])
+
+	case msg1["message"] != "Testmessage1":
+		t.Fatalf("Expected message to be Testmessage1 but got %v", msg1["message"])
+
+	case msg2["message"] != "Testmessage2":
+		t.Fatalf("Expected message to be Testmessage2 without newline but got %q", msg2["message"])
+
+	case msg1["service"] != "llogger-test":
+		t.Fatalf("Expected service to be llogger-test but got %v", msg1["service"])
+
+	// Check that resource is the caller of log.
+	case !strings.HasSuffix(res["file"].(string), "stdlog_test.go"):
+		t.Fatalf("Expected resource file to be stdlog_test.go but got %v", res["file"])
+	}
+}
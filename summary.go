@@ -0,0 +1,78 @@
+package llogger
+
+import (
+	"sync"
+	"time"
+)
+
+// counter counts the printed messages per log level for the
+// summary. It's shared between a client and the clients derived
+// from it and safe for concurrent use.
+type counter struct {
+	mu      sync.Mutex
+	counts  map[string]int
+	printed bool
+
+	// If true the percentiles of the invocation durations over
+	// the lifetime of the container are added to the summary.
+	percentiles bool
+}
+
+// setSummary will enable the summary if llogger-summary or
+// llogger-percentiles is true in l.data.
+func (l *Client) setSummary() {
+	summary, _ := l.configBool("llogger-summary")
+	percentiles, _ := l.configBool("llogger-percentiles")
+	if summary || percentiles {
+		l.counter = &counter{counts: map[string]int{}, percentiles: percentiles}
+	}
+}
+
+// count will count a printed message with log level level if the
+// summary is enabled. Messages without a log level are not counted.
+func (l *Client) count(level interface{}) {
+	str, ok := level.(string)
+	if l.counter == nil || !ok || str == "" {
+		return
+	}
+
+	l.counter.mu.Lock()
+	defer l.counter.mu.Unlock()
+
+	l.counter.counts[str]++
+}
+
+// printSummary will print the number of printed messages per log level
+// in the counts field, with log level info, if the summary is enabled.
+// If percentiles are enabled the duration of the invocation, since the
+// client was created or got its context, is added to the invocations
+// histogram and the p50 and p95 invocation durations in seconds over
+// the lifetime of the container are added in the durationP50 and
+// durationP95 fields. The summary is only printed once.
+func (l *Client) printSummary() {
+	if l.counter == nil {
+		return
+	}
+
+	l.counter.mu.Lock()
+	if l.counter.printed {
+		l.counter.mu.Unlock()
+		return
+	}
+	l.counter.printed = true
+
+	counts := map[string]int{}
+	for level, n := range l.counter.counts {
+		counts[level] = n
+	}
+	l.counter.mu.Unlock()
+
+	inp := Input{l.llfn: "info", l.mfn: "Summary", "counts": counts}
+	if l.counter.percentiles {
+		invocations.add(time.Since(l.start))
+		inp["durationP50"] = invocations.percentile(50).Seconds()
+		inp["durationP95"] = invocations.percentile(95).Seconds()
+	}
+
+	l.print(call{skip: 3, force: true}, inp)
+}
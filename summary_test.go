@@ -0,0 +1,46 @@
+package llogger
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestSummary will test that Close prints the number of
+// printed messages per log level.
+func TestSummary(t *testing.T) {
+	buf := &syncBuffer{}
+	client := Create(nil, Input{"llogger-writer": buf, "llogger-summary": true, "llogger-minlevel": "info"})
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Info("Testmessage1", nil)
+		}()
+	}
+	wg.Wait()
+	client.Debug("Testmessage2", nil)
+	client.WithFields(Input{"service": "llogger-test"}).Warn("Testmessage3", nil)
+	client.Warn("Testmessage4", nil)
+	client.Error("Testmessage5", nil)
+	client.Print(Input{"message": "Testmessage6"})
+	client.Close()
+	client.Close()
+
+	strs := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	summary := decode(t, strs[len(strs)-1])
+	counts, _ := summary["counts"].(map[string]interface{})
+
+	switch {
+	case len(strs) != 15:
+		t.Fatalf("Expected 14 messages and the summary but got %d", len(strs))
+
+	case summary["message"] != "Summary" || summary["loglevel"] != "info":
+		t.Fatalf("Expected the summary last but got %s", strs[len(strs)-1])
+
+	case len(counts) != 3 || counts["info"] != float64(10) || counts["warning"] != float64(2) || counts["error"] != float64(1):
+		t.Fatalf("Expected counts to be info 10, warning 2 and error 1 but got %v", counts)
+	}
+}
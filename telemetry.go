@@ -0,0 +1,23 @@
+package llogger
+
+import (
+	"time"
+)
+
+// telemetryTimeFormat is the time format of the Telemetry API.
+const telemetryTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// toTelemetry takes the JSON message raw printed at t and returns
+// it as an AWS Lambda Telemetry API record of type function, with
+// the time in UTC and raw as the record. For example
+// {"time":"2022-10-12T00:03:50.000Z","type":"function","record":{...}}.
+// Returns []byte.
+func toTelemetry(raw []byte, t time.Time) []byte {
+	b := make([]byte, 0, len(raw)+64)
+	b = append(b, `{"time":"`...)
+	b = t.UTC().AppendFormat(b, telemetryTimeFormat)
+	b = append(b, `","type":"function","record":`...)
+	b = append(b, raw...)
+
+	return append(b, '}')
+}
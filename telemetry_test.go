@@ -0,0 +1,38 @@
+package llogger
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTelemetry will test that messages are wrapped in a
+// Telemetry API record when llogger-format is telemetry.
+func TestTelemetry(t *testing.T) {
+	client := Create(nil, Input{"llogger-format": "telemetry", "service": "llogger-test"})
+	start := time.Now().UTC().Truncate(time.Millisecond)
+
+	strs := capture(t, func() {
+		client.Info("Testmessage1", Input{"id": 1})
+	})
+
+	msg := decode(t, strs[0])
+	record, _ := msg["record"].(map[string]interface{})
+	ts, err := time.Parse(telemetryTimeFormat, msg["time"].(string))
+
+	switch {
+	case msg["type"] != "function":
+		t.Fatalf("Expected type to be function but got %s", strs[0])
+
+	case err != nil || ts.Before(start) || ts.After(time.Now()):
+		t.Fatalf("Expected time to be in the Telemetry API format but got %s", strs[0])
+
+	case len(msg) != 3:
+		t.Fatalf("Expected only time, type and record but got %s", strs[0])
+
+	case record["message"] != "Testmessage1" || record["loglevel"] != "info" || record["service"] != "llogger-test" || record["id"] != float64(1):
+		t.Fatalf("Expected the normal fields in record but got %s", strs[0])
+
+	case record["resource"] == nil || record["time"] == nil:
+		t.Fatalf("Expected the built in fields in record but got %s", strs[0])
+	}
+}
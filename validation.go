@@ -0,0 +1,32 @@
+package llogger
+
+// ValidationErrors prints errs, a map of field names to validation
+// messages, as an object in field with the message Validation failed.
+// An empty field means validationErrors, so that validation failures
+// look the same in all logs. The log level is set by llogger-vlevel
+// and defaults to the warning log level set by llogger-wm. Nothing is
+// printed if errs is empty.
+func (l *Client) ValidationErrors(field string, errs map[string]string, inp Input) {
+	l = l.orDefault()
+	if len(errs) == 0 {
+		return
+	}
+	if field == "" {
+		field = "validationErrors"
+	}
+
+	level := l.validationLevel
+	if level == "" {
+		level = l.wm
+	}
+
+	fields := make(map[string]interface{}, len(errs))
+	for k, v := range errs {
+		fields[k] = v
+	}
+
+	data := l.leveled(level, "Validation failed", inp)
+	data[field] = fields
+
+	l.print(call{skip: 2}, data)
+}
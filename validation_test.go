@@ -0,0 +1,38 @@
+package llogger
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestValidationErrors will test that validation errors are printed
+// as an object in the validationErrors field, or the given field.
+func TestValidationErrors(t *testing.T) {
+	client1 := Create(nil, Input{"llogger-wm": "warning"})
+	client2 := Create(nil, Input{"llogger-vlevel": "info"})
+	errs := map[string]string{"email": "is required", "age": "must be positive"}
+
+	strs := capture(t, func() {
+		client1.ValidationErrors("", errs, Input{"requestId": "1337"})
+		client2.ValidationErrors("invalid", errs, nil)
+		client1.ValidationErrors("", nil, nil)
+	})
+
+	if len(strs) != 2 {
+		t.Fatalf("Expected 2 lines from stdout since empty errors are not printed but got %q", strs)
+	}
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	want := map[string]interface{}{"email": "is required", "age": "must be positive"}
+
+	switch {
+	case msg1["loglevel"] != "warning" || msg1["message"] != "Validation failed" || msg1["requestId"] != "1337":
+		t.Fatalf("Expected warning with message Validation failed in msg1 but got %s", strs[0])
+
+	case !reflect.DeepEqual(msg1["validationErrors"], want):
+		t.Fatalf("Expected the errors in validationErrors in msg1 but got %s", strs[0])
+
+	case msg2["loglevel"] != "info" || !reflect.DeepEqual(msg2["invalid"], want) || msg2["validationErrors"] != nil:
+		t.Fatalf("Expected info with the errors in invalid in msg2 but got %s", strs[1])
+	}
+}
@@ -0,0 +1,375 @@
+package llogger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxBuffer is the size in bytes at which a buffered
+// client flushes its buffer even if Sync isn't called.
+const maxBuffer = 64 * 1024
+
+// sink is where a client writes its messages. It's safe
+// for concurrent use.
+type sink struct {
+	mu       sync.Mutex
+	out      *sinkOut
+	buffered bool // Buffer messages until Sync is called
+	buf      bytes.Buffer
+	closing  bool  // Close has been called
+	closed   bool  // Close has flushed the buffer
+	err      error // Last error from a message that couldn't be encoded
+
+	// If true the messages are buffered as the elements of a
+	// JSON array, which is written when the buffer is flushed.
+	array bool
+}
+
+// sinkOut is the writer of a sink and its circuit breaker. It's
+// shared by the sinks of the invocations of a client, see fork.
+type sinkOut struct {
+	mu      sync.Mutex
+	w       io.Writer // Writer to write to, nil means os.Stdout
+	breaker *breaker
+}
+
+// fork will return a new sink with the settings of s and an empty
+// buffer that writes to the same writer as s, through the same
+// circuit breaker.
+// Returns *sink.
+func (s *sink) fork() *sink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return &sink{out: s.out, buffered: s.buffered, array: s.array}
+}
+
+// monitor runs in its own goroutine while a client has a
+// deadline. It flushes the client before the deadline and
+// prints the deadline messages.
+type monitor struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// halt will stop m and wait for its goroutine to exit. It's
+// safe to call more than once and from several goroutines.
+func (m *monitor) halt() {
+	m.once.Do(func() { close(m.stop) })
+	<-m.done
+}
+
+// monitorState holds the running deadline monitor of a client
+// and the operation set with SetOperation. It's shared between
+// a client and the clients derived from it.
+type monitorState struct {
+	mu        sync.Mutex
+	running   *monitor
+	operation string
+}
+
+// setWriter will set the writer and buffering from l.data.
+// The writer defaults to os.Stdout and messages are written
+// directly unless llogger-buffered is true.
+func (l *Client) setWriter() {
+	l.sink = &sink{out: &sinkOut{}}
+
+	if w, ok := l.data["llogger-writer"]; ok {
+		if w, ok := w.(io.Writer); ok {
+			l.sink.out.w = w
+		}
+		delete(l.data, "llogger-writer")
+	}
+
+	if onError, ok := l.data["llogger-onerror"]; ok {
+		switch fn := onError.(type) {
+		case ErrorHandler:
+			l.onError = fn
+
+		case func(error):
+			l.onError = fn
+		}
+		delete(l.data, "llogger-onerror")
+	}
+
+	if buffered, ok := l.configBool("llogger-buffered"); ok {
+		l.sink.buffered = buffered
+	}
+
+	if d, ok := l.configDuration("llogger-flushbefore"); ok && d > 0 {
+		l.flushBefore = d
+	}
+
+	if on, ok := l.configBool("llogger-deadlinelog"); ok {
+		l.deadlineLogs = on
+	}
+
+	if on, ok := l.configBool("llogger-keepcontext"); ok {
+		l.keepContext = on
+	}
+
+	// Stop writing for a while if the writer keeps failing.
+	l.setBreaker()
+
+	// Use console when writing to a terminal and json otherwise.
+	if l.format == "auto" {
+		l.format = "json"
+		if isTerminal(l.sink.out.writer()) {
+			l.format = "console"
+		}
+	}
+
+	// Buffer the messages as a JSON array, for formats that are JSON.
+	if array, _ := l.configBool("llogger-array"); array && l.format != "msgpack" && l.format != "console" {
+		l.sink.array = true
+		l.sink.buffered = true
+	}
+}
+
+// isTerminal will return if w is a file that is a terminal, or rather a
+// character device since that's what can be checked without syscalls
+// for each platform.
+// Returns bool.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// write will write p to the writer of l, or to the buffer if
+// l is buffered. The buffer is flushed when it reaches maxBuffer.
+// Returns error.
+func (l *Client) write(p []byte) error {
+	s := l.sink
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.array {
+		return s.writeArray(p)
+	}
+
+	if !s.buffered || s.closed {
+		return s.writeOut(p)
+	}
+
+	s.buf.Write(p)
+	if s.buf.Len() >= maxBuffer {
+		return s.flush()
+	}
+
+	return nil
+}
+
+// Sync writes all buffered messages to the writer.
+// It's a no-op if the client isn't buffered.
+// Returns error.
+func (l *Client) Sync() error {
+	l = l.orDefault()
+	s := l.sink
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.flush()
+}
+
+// Close stops the deadline, memory and shutdown monitors, prints the
+// summary if llogger-summary is true and writes all buffered messages
+// to the writer. Messages printed after Close are written directly to
+// the writer. Calling Close more than once is a no-op.
+// Returns error.
+func (l *Client) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.stopMonitor()
+	l.stopMemoryMonitor()
+	l.stopShutdownMonitor()
+
+	// Check and set closing under the lock so that only one of
+	// several concurrent calls prints the summary and flushes.
+	s := l.sink
+	s.mu.Lock()
+	closing := s.closing
+	s.closing = true
+	s.mu.Unlock()
+
+	if closing {
+		return nil
+	}
+	l.printSummary()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	return s.flush()
+}
+
+// Shutdown does the same as Close but gives up waiting when ctx is done,
+// for example if the writer blocks, and then returns the error of ctx.
+// Close keeps running in the background in that case. This makes it
+// safe to defer in a handler with defer log.Shutdown(ctx). Calling
+// Shutdown more than once is a no-op. If ctx is nil it waits for Close.
+// Returns error.
+func (l *Client) Shutdown(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	if ctx == nil {
+		return l.Close()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Close()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// writer will return o.w or os.Stdout if o.w is nil. os.Stdout
+// is looked up on each write so that it can be redirected.
+// Returns io.Writer.
+func (o *sinkOut) writer() io.Writer {
+	if o.w == nil {
+		return os.Stdout
+	}
+
+	return o.w
+}
+
+// writeArray will add the JSON message p to the JSON array in the
+// buffer. The buffer isn't flushed when it reaches maxBuffer, so that
+// all messages until Close are written as one array. After Close p is
+// written directly as an array of its own. s.mu must be held.
+// Returns error.
+func (s *sink) writeArray(p []byte) error {
+	if s.closed {
+		line := make([]byte, 0, len(p)+3)
+		return s.writeOut(append(append(append(line, '['), p...), ']', '\n'))
+	}
+
+	if s.buf.Len() == 0 {
+		s.buf.WriteByte('[')
+	} else {
+		s.buf.WriteByte(',')
+	}
+	s.buf.Write(p)
+
+	return nil
+}
+
+// flush will write the buffer to the writer. All buffered messages
+// are written in a single write, which for stdout means a single
+// syscall. Each message still ends with its own newline, except
+// in array mode where the array is closed and ends with a newline.
+// s.mu must be held.
+// Returns error.
+func (s *sink) flush() error {
+	if s.buf.Len() == 0 {
+		return nil
+	}
+	if s.array {
+		s.buf.WriteString("]\n")
+	}
+
+	err := s.writeOut(s.buf.Bytes())
+	s.buf.Reset()
+	return err
+}
+
+// startMonitor will start the deadline monitor for l.deadline,
+// stopping any running monitor first. The monitor flushes l
+// l.flushBefore before the deadline if l is buffered and prints
+// the deadline messages if l.deadlineLogs is set. It's not started
+// if l is closed or if it has nothing to do.
+func (l *Client) startMonitor() {
+	l.stopMonitor()
+
+	l.sink.mu.Lock()
+	closed := l.sink.closed
+	l.sink.mu.Unlock()
+
+	flush := l.sink.buffered && l.flushBefore > 0
+	left := time.Until(l.deadline)
+	if closed || (!flush && (!l.deadlineLogs || left <= 0)) {
+		return
+	}
+
+	// Channels of disabled timers are left nil so they never fire.
+	var flushC, warnC, critC <-chan time.Time
+	timers := []*time.Timer{}
+	if flush {
+		t := time.NewTimer(time.Until(l.deadline.Add(-l.flushBefore)))
+		flushC, timers = t.C, append(timers, t)
+	}
+	if l.deadlineLogs && left > 0 {
+		warn, crit := time.NewTimer(left*3/4), time.NewTimer(left*9/10)
+		warnC, critC, timers = warn.C, crit.C, append(timers, warn, crit)
+	}
+
+	m := &monitor{stop: make(chan struct{}), done: make(chan struct{})}
+
+	l.monitor.mu.Lock()
+	l.monitor.running = m
+	l.monitor.mu.Unlock()
+
+	go func() {
+		defer close(m.done)
+		defer func() {
+			for _, t := range timers {
+				t.Stop()
+			}
+		}()
+
+		for flushC != nil || warnC != nil || critC != nil {
+			select {
+			case <-flushC:
+				flushC = nil
+				l.Sync()
+
+			case <-warnC:
+				warnC = nil
+				l.deadlineLog(l.wm, 25)
+
+			case <-critC:
+				critC = nil
+				l.deadlineLog(l.cm, 10)
+
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopMonitor will stop the deadline monitor of l if it's
+// running and wait for it to exit.
+func (l *Client) stopMonitor() {
+	l.monitor.mu.Lock()
+	m := l.monitor.running
+	l.monitor.running = nil
+	l.monitor.mu.Unlock()
+
+	if m == nil {
+		return
+	}
+
+	m.halt()
+}
@@ -0,0 +1,306 @@
+package llogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestWriter will test writing to a custom writer.
+func TestWriter(t *testing.T) {
+	buf := &syncBuffer{}
+	client := Create(nil, Input{"llogger-writer": buf})
+
+	client.Print(Input{"message": "Testmessage1"})
+
+	if !strings.Contains(buf.String(), "Testmessage1") {
+		t.Fatalf("Expected Testmessage1 to be written to writer but got %s", buf.String())
+	}
+}
+
+// TestBuffered will test that buffered messages are only
+// written on Sync and Close.
+func TestBuffered(t *testing.T) {
+	buf := &syncBuffer{}
+	client := Create(nil, Input{"llogger-writer": buf, "llogger-buffered": true})
+
+	client.Print(Input{"message": "Testmessage1"})
+	if buf.String() != "" {
+		t.Fatalf("Expected nothing to be written before Sync but got %s", buf.String())
+	}
+
+	client.Sync()
+	if !strings.Contains(buf.String(), "Testmessage1") {
+		t.Fatalf("Expected Testmessage1 to be written after Sync but got %s", buf.String())
+	}
+
+	client.Print(Input{"message": "Testmessage2"})
+	client.Close()
+	if !strings.Contains(buf.String(), "Testmessage2") {
+		t.Fatalf("Expected Testmessage2 to be written after Close but got %s", buf.String())
+	}
+
+	// Check that messages are written directly after Close.
+	client.Print(Input{"message": "Testmessage3"})
+	if !strings.Contains(buf.String(), "Testmessage3") {
+		t.Fatalf("Expected Testmessage3 to be written directly after Close but got %s", buf.String())
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Expected second Close to be a no-op but got %s", err.Error())
+	}
+}
+
+// TestFlushBefore will test that buffered messages are flushed
+// automatically before the deadline.
+func TestFlushBefore(t *testing.T) {
+	buf := &syncBuffer{}
+	deadline := time.Now().Add(300 * time.Millisecond)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	client := Create(ctx, Input{"llogger-writer": buf, "llogger-buffered": true, "llogger-flushbefore": "200ms"})
+	defer client.Close()
+
+	client.Print(Input{"message": "Testmessage1"})
+	if buf.String() != "" {
+		t.Fatalf("Expected nothing to be written before the flush but got %s", buf.String())
+	}
+
+	// Wait until after the flush but before the deadline.
+	time.Sleep(time.Until(deadline.Add(-50 * time.Millisecond)))
+	if !strings.Contains(buf.String(), "Testmessage1") {
+		t.Fatalf("Expected Testmessage1 to be flushed before the deadline but got %s", buf.String())
+	}
+}
+
+// TestFlushBeforeClosed will test that the automatic flush
+// doesn't happen after Close.
+func TestFlushBeforeClosed(t *testing.T) {
+	buf := &syncBuffer{}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	client := Create(ctx, Input{"llogger-writer": buf, "llogger-buffered": true, "llogger-flushbefore": 50 * time.Millisecond})
+	client.Close()
+
+	client.monitor.mu.Lock()
+	m := client.monitor.running
+	client.monitor.mu.Unlock()
+	if m != nil {
+		t.Fatalf("Expected the monitor to be stopped after Close")
+	}
+}
+
+// countingWriter counts the number of writes.
+type countingWriter struct {
+	syncBuffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.syncBuffer.Write(p)
+}
+
+// TestBufferedSingleWrite will test that all buffered messages
+// are written as newline delimited JSON in a single write.
+func TestBufferedSingleWrite(t *testing.T) {
+	w := &countingWriter{}
+	client := Create(nil, Input{"llogger-writer": w, "llogger-buffered": true})
+
+	for i := 0; i < 10; i++ {
+		client.Print(Input{"message": "Testmessage", "i": i})
+	}
+	client.Sync()
+
+	if w.writes != 1 {
+		t.Fatalf("Expected 1 write for 10 buffered messages but got %d", w.writes)
+	}
+
+	strs := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	if len(strs) != 10 {
+		t.Fatalf("Expected 10 lines in the write but got %d", len(strs))
+	}
+	for i, str := range strs {
+		if msg := decode(t, str); msg["i"] != float64(i) {
+			t.Fatalf("Expected line %d to be message %d but got %s", i, i, str)
+		}
+	}
+}
+
+// blockingWriter blocks all writes until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+}
+
+// Write blocks until release is closed.
+// Returns int and error.
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+// TestShutdown will test that Shutdown flushes the buffer and
+// stops the monitor when deferred in a handler.
+func TestShutdown(t *testing.T) {
+	buf := &syncBuffer{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var client *Client
+	func() {
+		client = Create(ctx, Input{"llogger-writer": buf, "llogger-buffered": true, "llogger-flushbefore": "200ms", "llogger-summary": true})
+		defer client.Shutdown(ctx)
+
+		client.Info("Testmessage1", nil)
+	}()
+
+	client.monitor.mu.Lock()
+	m := client.monitor.running
+	client.monitor.mu.Unlock()
+
+	switch {
+	case !strings.Contains(buf.String(), "Testmessage1") || !strings.Contains(buf.String(), `"counts":{"info":1}`):
+		t.Fatalf("Expected Testmessage1 and the summary to be flushed but got %s", buf.String())
+
+	case m != nil:
+		t.Fatalf("Expected the monitor to be stopped after Shutdown")
+
+	case client.Shutdown(ctx) != nil:
+		t.Fatalf("Expected second Shutdown to be a no-op")
+	}
+}
+
+// TestShutdownTimeout will test that Shutdown returns the
+// error of ctx when the flush doesn't finish in time.
+func TestShutdownTimeout(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	defer close(w.release)
+
+	client := Create(nil, Input{"llogger-writer": w, "llogger-buffered": true})
+	client.Print(Input{"message": "Testmessage1"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := client.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expected Shutdown to return context.DeadlineExceeded but got %v", err)
+	}
+}
+
+// TestFormatAuto will test that the auto format is console
+// for terminals and json for other writers.
+func TestFormatAuto(t *testing.T) {
+	tty, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skipf("Couldn't open %s: %s", os.DevNull, err.Error())
+	}
+	defer tty.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Couldn't create pipe: %s", err.Error())
+	}
+	defer r.Close()
+	defer w.Close()
+
+	// The null device is a character device so it's detected as a terminal.
+	client1 := Create(nil, Input{"llogger-format": "auto", "llogger-writer": tty})
+	client2 := Create(nil, Input{"llogger-format": "auto", "llogger-writer": w})
+	client3 := Create(nil, Input{"llogger-format": "auto", "llogger-writer": &syncBuffer{}})
+	client4 := Create(nil, Input{"llogger-writer": tty})
+
+	switch {
+	case client1.format != "console":
+		t.Fatalf("Expected format console for a terminal but got %s", client1.format)
+
+	case client2.format != "json" || client3.format != "json":
+		t.Fatalf("Expected format json for a pipe and a buffer but got %s and %s", client2.format, client3.format)
+
+	case client4.format != "json":
+		t.Fatalf("Expected format json by default but got %s", client4.format)
+	}
+}
+
+// TestArray will test that all messages until Close are written
+// as a single JSON array.
+func TestArray(t *testing.T) {
+	buf := &countingWriter{}
+	client := Create(nil, Input{"llogger-writer": buf, "llogger-array": true, "llogger-prefix": "pre "})
+
+	for i := 0; i < 3; i++ {
+		client.Print(Input{"message": "Testmessage"})
+	}
+	client.WithFields(Input{"service": "llogger-test"}).Print(Input{"message": "Testmessage"})
+
+	if buf.writes != 0 {
+		t.Fatalf("Expected nothing to be written before Close but got %q", buf.String())
+	}
+
+	client.Close()
+	var msgs []map[string]interface{}
+	if err := json.Unmarshal([]byte(buf.String()), &msgs); err != nil {
+		t.Fatalf("Expected a valid JSON array but got %q. Error %s", buf.String(), err.Error())
+	}
+
+	switch {
+	case buf.writes != 1 || !strings.HasSuffix(buf.String(), "]\n"):
+		t.Fatalf("Expected the array to be written in a single write but got %d writes", buf.writes)
+
+	case len(msgs) != 4 || msgs[0]["message"] != "Testmessage" || msgs[3]["service"] != "llogger-test":
+		t.Fatalf("Expected 4 messages in the array but got %q", buf.String())
+	}
+
+	// Messages after Close are written as arrays of their own.
+	client.Print(Input{"message": "Testmessage5"})
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], `[{"`) {
+		t.Fatalf("Expected the message after Close as an array of its own but got %q", buf.String())
+	}
+}
+
+// TestConcurrentClose will test that the summary is only printed and
+// the buffer only flushed once when Close is called concurrently.
+func TestConcurrentClose(t *testing.T) {
+	buf := &syncBuffer{}
+	client := Create(nil, Input{"llogger-writer": buf, "llogger-buffered": true, "llogger-summary": true})
+	client.Info("Testmessage1", nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Shutdown(context.Background())
+		}()
+	}
+	wg.Wait()
+	client.Close()
+
+	strs := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(strs) != 2 || !strings.Contains(strs[0], "Testmessage1") || !strings.Contains(strs[1], `"message":"Summary"`) {
+		t.Fatalf("Expected the message and one summary but got %q", strs)
+	}
+}
@@ -0,0 +1,67 @@
+package llogger
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// traceHeader will return the X-Ray trace header of the invocation. The
+// Lambda Go runtime sets it as the x-amzn-trace-id value of the context
+// and in the _X_AMZN_TRACE_ID environment variable. The context of the
+// call is checked first, then the context of l and last the environment.
+// Returns string.
+func (l *Client) traceHeader(c call) string {
+	for _, ctx := range []context.Context{c.ctx, l.context} {
+		if ctx == nil {
+			continue
+		}
+		if header, ok := ctx.Value("x-amzn-trace-id").(string); ok && header != "" {
+			return header
+		}
+	}
+
+	return os.Getenv("_X_AMZN_TRACE_ID")
+}
+
+// parseTraceHeader will parse the X-Ray trace header header, such as
+// "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1",
+// and return the trace ID from Root and the Sampled flag. hasSampled is
+// false if the header has no valid Sampled segment.
+// Returns string, bool and bool.
+func parseTraceHeader(header string) (root string, sampled bool, hasSampled bool) {
+	for _, segment := range strings.Split(header, ";") {
+		kv := strings.SplitN(strings.TrimSpace(segment), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		switch kv[0] {
+		case "Root":
+			root = kv[1]
+
+		case "Sampled":
+			if kv[1] == "1" || kv[1] == "0" {
+				sampled, hasSampled = kv[1] == "1", true
+			}
+		}
+	}
+
+	return root, sampled, hasSampled
+}
+
+// addTraceFields will add the trace ID and the trace sampled flag
+// from the X-Ray trace header to out, if enabled and present.
+func (l *Client) addTraceFields(c call, out output) {
+	if l.xifn == "" && l.xsfn == "" {
+		return
+	}
+
+	root, sampled, hasSampled := parseTraceHeader(l.traceHeader(c))
+	if l.xifn != "" && root != "" {
+		out[l.xifn] = root
+	}
+	if l.xsfn != "" && hasSampled {
+		out[l.xsfn] = sampled
+	}
+}
@@ -0,0 +1,61 @@
+package llogger
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// TestParseTraceHeader will test parsing X-Ray trace headers
+// with and without the Sampled segment.
+func TestParseTraceHeader(t *testing.T) {
+	root1, sampled1, has1 := parseTraceHeader("Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1")
+	root2, _, has2 := parseTraceHeader("Root=1-5759e988-bd862e3fe1be46a994272793")
+	_, sampled3, has3 := parseTraceHeader("Sampled=0")
+	root4, _, has4 := parseTraceHeader("")
+
+	switch {
+	case root1 != "1-5759e988-bd862e3fe1be46a994272793" || !sampled1 || !has1:
+		t.Fatalf("Expected root and sampled true but got %s, %t and %t", root1, sampled1, has1)
+
+	case root2 != "1-5759e988-bd862e3fe1be46a994272793" || has2:
+		t.Fatalf("Expected root and no sampled but got %s and %t", root2, has2)
+
+	case sampled3 || !has3:
+		t.Fatalf("Expected sampled false but got %t and %t", sampled3, has3)
+
+	case root4 != "" || has4:
+		t.Fatalf("Expected nothing from an empty header but got %s and %t", root4, has4)
+	}
+}
+
+// TestTraceSampled will test that the trace fields are set from
+// the context or the environment when enabled.
+func TestTraceSampled(t *testing.T) {
+	os.Setenv("_X_AMZN_TRACE_ID", "Root=1-env;Parent=53995c3f42cd8ad8")
+	defer os.Unsetenv("_X_AMZN_TRACE_ID")
+
+	client1 := Create(nil, Input{"llogger-xray": true})
+	client2 := Create(nil, nil)
+	ctx := context.WithValue(context.Background(), "x-amzn-trace-id", "Root=1-ctx;Sampled=1")
+
+	strs := capture(t, func() {
+		client1.PrintContext(ctx, Input{"message": "Testmessage1"})
+		client1.Print(Input{"message": "Testmessage2"})
+		client2.PrintContext(ctx, Input{"message": "Testmessage3"})
+	})
+	msg1 := decode(t, strs[0])
+	msg2 := decode(t, strs[1])
+	msg3 := decode(t, strs[2])
+
+	switch {
+	case msg1["traceId"] != "1-ctx" || msg1["traceSampled"] != true:
+		t.Fatalf("Expected trace fields from the context in msg1 but got %s", strs[0])
+
+	case msg2["traceId"] != "1-env" || msg2["traceSampled"] != nil:
+		t.Fatalf("Expected traceId from the environment and no traceSampled in msg2 but got %s", strs[1])
+
+	case msg3["traceId"] != nil || msg3["traceSampled"] != nil:
+		t.Fatalf("Expected no trace fields when not enabled in msg3 but got %s", strs[2])
+	}
+}